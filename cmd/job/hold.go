@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package job_cmd
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2/log"
+	"time"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/config"
+	"xyz/pkg/otel"
+
+	"github.com/spf13/cobra"
+)
+
+// holdCmd represents the limit hold expiry job command
+var holdCmd = &cobra.Command{
+	Use:   "holds",
+	Short: "Expire limit holds",
+	Long: `Mark active limit holds that passed their expiry time as expired.
+Expired holds are already excluded from the available limit, so this job only cleans up the hold status.
+Can be run as often as needed`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		otel.InitTelemetry(ctx, "xyz-job")
+		defer otel.Shutdown()
+
+		db := config.InitDatabase()
+		svc := service.NewHoldService(repository.NewUserRepository(db), repository.NewTransactionRepository(db), repository.NewPricingRepository(db), repository.NewMerchantRepository(db))
+
+		count, err := svc.Expire(ctx, time.Now())
+		if err != nil {
+			log.Fatalf("Failed to expire limit holds: %s", err.Error())
+		}
+
+		log.Infof("Limit holds: %d expired", count)
+	},
+}
+
+func init() {
+	jobCmd.AddCommand(holdCmd)
+}
@@ -0,0 +1,23 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package job_cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// jobCmd represents the job command
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Scheduled jobs",
+	Long:  `Jobs that should be run periodically, e.g. from cron`,
+}
+
+func Init() *cobra.Command {
+	return jobCmd
+}
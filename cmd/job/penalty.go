@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package job_cmd
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2/log"
+	"time"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/config"
+	"xyz/pkg/otel"
+
+	"github.com/spf13/cobra"
+)
+
+var penaltyDate string
+
+// penaltyCmd represents the penalty job command
+var penaltyCmd = &cobra.Command{
+	Use:   "penalty",
+	Short: "Accrue late payment penalty",
+	Long: `Find overdue installments and accrue late payment penalty (denda) for the date.
+Should be run once a day. Running it more than once on the same date is safe`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		date := time.Now()
+		if penaltyDate != "" {
+			var err error
+			date, err = time.ParseInLocation(time.DateOnly, penaltyDate, time.Local)
+			if err != nil {
+				log.Fatal("Invalid date format, use YYYY-MM-DD")
+			}
+		}
+
+		otel.InitTelemetry(ctx, "xyz-job")
+		defer otel.Shutdown()
+
+		db := config.InitDatabase()
+		svc := service.NewPenaltyService(repository.NewTransactionRepository(db))
+
+		result, err := svc.Accrue(ctx, date)
+		if err != nil {
+			log.Fatalf("Failed to accrue penalty: %s", err.Error())
+		}
+
+		log.Infof("Penalty %s: %d overdue, %d accrued, %d skipped, %d failed, total %s", result.Date, result.Overdue, result.Accrued, result.Skipped, result.Failed, result.TotalAmount)
+	},
+}
+
+func init() {
+	jobCmd.AddCommand(penaltyCmd)
+
+	penaltyCmd.Flags().StringVar(&penaltyDate, "date", "", "Accrual date (YYYY-MM-DD), default today")
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package reconcile_cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2/log"
+	"io"
+	"os"
+	"strconv"
+	"xyz/internal/dto"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/config"
+	"xyz/pkg/otel"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	limitsBatchSize int    = 500
+	limitsFormat    string = "json"
+	limitsApply     bool   = false
+)
+
+// limitsCmd represents the limit reconciliation command
+var limitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "Reconcile credit lines and tenor limits with transactions and limit holds",
+	Long: `Compare the available amount of every credit line and tenor limit with the granted limit minus the unpaid transactions and the active limit holds.
+Mismatches are written to stdout as JSON or CSV, and the command exits with code 1 if there is any drift.
+With --apply, the drift is corrected with reconciliation entries in the limit ledger, the exit code is 0 if all of them are fixed`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if limitsFormat != "json" && limitsFormat != "csv" {
+			return fmt.Errorf("invalid format %q, valid values are json or csv", limitsFormat)
+		}
+		if limitsBatchSize < 1 {
+			return fmt.Errorf("invalid batch size %d", limitsBatchSize)
+		}
+
+		ctx := context.Background()
+
+		otel.InitTelemetry(ctx, "xyz-reconcile")
+		defer otel.Shutdown()
+
+		db := config.InitDatabase()
+		svc := service.NewLimitService(repository.NewUserRepository(db), repository.NewTransactionRepository(db))
+
+		result, err := svc.Reconcile(ctx, limitsBatchSize, limitsApply)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile limits: %w", err)
+		}
+
+		if limitsFormat == "csv" {
+			err = writeLimitsCSV(os.Stdout, result)
+		} else {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(result)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+
+		log.Infof("Limit reconciliation: %d scanned, %d mismatches, %d applied, %d failed", result.Scanned, result.Mismatches, result.Applied, result.Failed)
+		if result.Mismatches > result.Applied {
+			return fmt.Errorf("limit drift found on %d limits", result.Mismatches-result.Applied)
+		}
+		return nil
+	},
+}
+
+func writeLimitsCSV(w io.Writer, result *dto.LimitReconcileResult) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"limit_type", "limit_id", "user_id", "tenor", "limit_amount", "held_amount", "available_amount", "granted_amount", "unpaid_amount", "outstanding_amount", "expected_amount", "drift", "applied"})
+	for _, item := range result.Items {
+		_ = writer.Write([]string{
+			item.LimitType,
+			item.LimitID,
+			item.UserID,
+			strconv.Itoa(item.Tenor),
+			item.LimitAmount.String(),
+			item.HeldAmount.String(),
+			item.AvailableAmount.String(),
+			item.GrantedAmount.String(),
+			item.UnpaidAmount.String(),
+			item.OutstandingAmount.String(),
+			item.ExpectedAmount.String(),
+			item.Drift.String(),
+			strconv.FormatBool(item.Applied),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func init() {
+	reconcileCmd.AddCommand(limitsCmd)
+
+	limitsCmd.Flags().IntVar(&limitsBatchSize, "batch-size", 500, "Number of users in one batch")
+	limitsCmd.Flags().StringVar(&limitsFormat, "format", "json", "Report format. Valid values are json or csv")
+	limitsCmd.Flags().BoolVar(&limitsApply, "apply", false, "Correct the drift with reconciliation entries in the limit ledger")
+}
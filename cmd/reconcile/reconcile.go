@@ -0,0 +1,23 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package reconcile_cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// reconcileCmd represents the reconcile command
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Data reconciliation",
+	Long:  `Operational checks that compare saved balances with their source records`,
+}
+
+func Init() *cobra.Command {
+	return reconcileCmd
+}
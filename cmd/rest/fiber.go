@@ -16,7 +16,6 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/earlydata"
 	"github.com/gofiber/fiber/v2/middleware/etag"
-	"github.com/gofiber/fiber/v2/middleware/idempotency"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	recover2 "github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
@@ -90,7 +89,7 @@ func New(ctx context.Context) *Rest {
 	app.Use(requestid.New())
 	app.Use(etag.New())
 	app.Use(earlydata.New())
-	app.Use(idempotency.New())
+	app.Use(middleware.Idempotency(fiberStorage))
 
 	// Inject otel
 	app.Use(func(c *fiber.Ctx) error {
@@ -114,16 +113,27 @@ func New(ctx context.Context) *Rest {
 	// REPO
 	userRepo := repository.NewUserRepository(db)
 	transactionRepo := repository.NewTransactionRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	pricingRepo := repository.NewPricingRepository(db)
+	merchantRepo := repository.NewMerchantRepository(db)
 
 	repoRegistry := repository.RepoRegistry{
 		UserRepository:        userRepo,
 		TransactionRepository: transactionRepo,
+		PaymentRepository:     paymentRepo,
+		PricingRepository:     pricingRepo,
+		MerchantRepository:    merchantRepo,
 	}
 
 	// ROUTER
 	router.UserRouterV1(app, repoRegistry)
 	router.AuthRouterV1(app, repoRegistry)
 	router.TransactionRouterV1(app, repoRegistry)
+	router.PaymentRouterV1(app, repoRegistry)
+	router.PricingRouterV1(app, repoRegistry)
+	router.HoldRouterV1(app, repoRegistry)
+	router.MerchantRouterV1(app, repoRegistry)
+	router.LimitRouterV1(app, repoRegistry)
 
 	app.Use(func(c *fiber.Ctx) error {
 		return response.EndpointNotFound().Response(c)
@@ -10,7 +10,9 @@ package cmd
 import (
 	"embed"
 	"log"
+	job_cmd "xyz/cmd/job"
 	migration_cmd "xyz/cmd/migration"
+	reconcile_cmd "xyz/cmd/reconcile"
 	"xyz/pkg/config"
 
 	"github.com/spf13/cobra"
@@ -32,6 +34,8 @@ func Execute(migrationEmbed embed.FS) {
 	cfg.MigrationEmbed = migrationEmbed
 
 	rootCmd.AddCommand(migration_cmd.Init(cfg))
+	rootCmd.AddCommand(job_cmd.Init())
+	rootCmd.AddCommand(reconcile_cmd.Init())
 
 	err := rootCmd.Execute()
 	if err != nil {
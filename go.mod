@@ -4,6 +4,7 @@ go 1.23.5
 
 require (
 	bou.ke/monkey v1.0.2
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/dromara/carbon/v2 v2.6.6
 	github.com/go-playground/validator/v10 v10.26.0
 	github.com/go-sql-driver/mysql v1.9.2
@@ -0,0 +1,31 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package dto
+
+// MerchantRequest creates or updates a merchant. Active is true when it is not sent on create
+type MerchantRequest struct {
+	Code    string `json:"code" validate:"required,max=50"`
+	Name    string `json:"name" validate:"required,max=255"`
+	Type    string `json:"type" validate:"required,oneof=dealer store online"`
+	Address string `json:"address" validate:"max=255"`
+	Active  *bool  `json:"active"`
+}
+
+type MerchantListRequest struct {
+	Pagination
+	Type   string `json:"type" query:"type" validate:"omitempty,oneof=dealer store online"`
+	Search string `json:"search" query:"search" validate:"max=255"`
+}
+
+// MerchantReportRequest is the query parameter of merchant report.
+//
+// DateFrom and DateTo use `YYYY-MM-DD` format, and both are inclusive
+type MerchantReportRequest struct {
+	DateFrom string `json:"date_from" query:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"date_to" query:"date_to" validate:"omitempty,datetime=2006-01-02"`
+}
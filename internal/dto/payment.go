@@ -0,0 +1,21 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package dto
+
+import "xyz/pkg/money"
+
+type PaymentRequest struct {
+	Amount    money.Money `json:"amount" validate:"required,gt=0"`
+	Reference string      `json:"reference" validate:"max=255"`
+}
+
+type SettleRequest struct {
+	QuoteID   string      `json:"quote_id" validate:"required"`
+	Amount    money.Money `json:"amount" validate:"required,gt=0"`
+	Reference string      `json:"reference" validate:"max=255"`
+}
@@ -0,0 +1,19 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package dto
+
+import "xyz/pkg/money"
+
+type PenaltyAccrualResult struct {
+	Date        string      `json:"date"`
+	Overdue     int         `json:"overdue"`
+	Accrued     int         `json:"accrued"`
+	Skipped     int         `json:"skipped"`
+	Failed      int         `json:"failed"`
+	TotalAmount money.Money `json:"total_amount"`
+}
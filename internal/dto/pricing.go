@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package dto
+
+import "xyz/pkg/money"
+
+// PricingVersionRequest creates new pricing version of a product.
+//
+// EffectiveFrom uses `YYYY-MM-DD` format, the version is used from the start of the day
+type PricingVersionRequest struct {
+	ProductCode           string               `json:"product_code" validate:"required,max=50"`
+	EffectiveFrom         string               `json:"effective_from" validate:"required,datetime=2006-01-02"`
+	InterestMethod        string               `json:"interest_method" validate:"required,oneof=flat effective"`
+	AdminFeeType          string               `json:"admin_fee_type" validate:"required,oneof=percent fixed"`
+	AdminFeeValue         float64              `json:"admin_fee_value" validate:"gte=0"`
+	AdminFeeBase          string               `json:"admin_fee_base" validate:"omitempty,oneof=principal principal_interest"`
+	AdminFeeMin           money.Money          `json:"admin_fee_min" validate:"gte=0"`
+	AdminFeeMax           money.Money          `json:"admin_fee_max" validate:"gte=0"`
+	MinDownPaymentPercent float64              `json:"min_down_payment_percent" validate:"gte=0,lt=100"` // Minimal uang muka dalam persen dari OTR
+	Rates                 []PricingRateRequest `json:"rates" validate:"required,min=1,dive"`
+}
+
+type PricingRateRequest struct {
+	Tenor        int     `json:"tenor" validate:"required,min=1"`
+	InterestRate float64 `json:"interest_rate" validate:"gte=0,lte=100"` // Bunga per tahun dalam persen
+}
+
+type PricingListRequest struct {
+	ProductCode string `json:"product_code" query:"product_code" validate:"max=50"`
+}
+
+// AssetCategoryRequest creates or updates financing rule of an asset category. Active is true when it is not sent on create
+type AssetCategoryRequest struct {
+	Code                  string      `json:"code" validate:"required,max=50"`
+	Name                  string      `json:"name" validate:"required,max=255"`
+	Tenors                []int       `json:"tenors" validate:"required,min=1,dive,min=1"`
+	MinOTR                money.Money `json:"min_otr" validate:"gte=0"`
+	MaxOTR                money.Money `json:"max_otr" validate:"gte=0"` // 0 jika tidak ada maksimal
+	MinDownPaymentPercent float64     `json:"min_down_payment_percent" validate:"gte=0,lt=100"`
+	Active                *bool       `json:"active"`
+}
@@ -7,8 +7,73 @@
 
 package dto
 
+import (
+	"xyz/pkg/money"
+	"xyz/pkg/pricing"
+)
+
 type TransactionRequest struct {
-	OTR       float64 `json:"otr" validate:"required"`
-	AssetName string  `json:"asset_name" validate:"required"`
-	Tenor     int     `json:"tenor" validate:"required,min=1,max=6"` // Tenor yang dipilih (1, 2, 3, atau 6 bulan)
+	OTR         money.Money `json:"otr" validate:"required"`
+	DownPayment money.Money `json:"down_payment" validate:"gte=0"` // Uang muka, tidak ikut dibiayai
+	AssetName   string      `json:"asset_name" validate:"required"`
+	Category    string      `json:"category" validate:"max=50"` // Kode kategori aset, aturan kategori tidak dicek jika kosong
+	Tenor       int         `json:"tenor" validate:"required"`  // Tenor yang dipilih, harus ada di pricing produk dan limit user
+	Product     string      `json:"product" validate:"max=50"`  // Kode produk, default jika kosong
+
+	MerchantID   string `json:"merchant_id" validate:"omitempty,max=36"`
+	SalesChannel string `json:"sales_channel" validate:"omitempty,oneof=offline online app"` // Default dari tipe merchant jika kosong
+}
+
+// SimulationRequest calculates the price of every tenor of the user, the price is calculated the same way as TransactionRequest
+type SimulationRequest struct {
+	OTR         money.Money `json:"otr" validate:"required"`
+	DownPayment money.Money `json:"down_payment" validate:"gte=0"`
+	AssetName   string      `json:"asset_name" validate:"max=255"`
+	Category    string      `json:"category" validate:"max=50"`
+	Product     string      `json:"product" validate:"max=50"`
+}
+
+// TenorSimulation is the simulated price of one tenor.
+//
+// Eligible is false if the tenor has no pricing or is not allowed by the asset category, Message explains why.
+// WithinLimit is true if the available limit of the tenor, capped by the available credit line, covers the total amount
+type TenorSimulation struct {
+	Tenor int `json:"tenor"`
+	pricing.Result
+	AvailableAmount money.Money `json:"available_amount"`
+	Eligible        bool        `json:"eligible"`
+	WithinLimit     bool        `json:"within_limit"`
+	Message         string      `json:"message,omitempty"`
+}
+
+type RejectTransactionRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// TransactionListRequest is the query parameter of user transactions list.
+//
+// DateFrom and DateTo use `YYYY-MM-DD` format, and both are inclusive
+type TransactionListRequest struct {
+	Pagination
+	Status   string `json:"status" query:"status" validate:"omitempty,oneof=pending approved rejected settled cancelled"`
+	Tenor    int    `json:"tenor" query:"tenor" validate:"omitempty,min=1"`
+	Search   string `json:"search" query:"search" validate:"max=255"`
+	DateFrom string `json:"date_from" query:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"date_to" query:"date_to" validate:"omitempty,datetime=2006-01-02"`
+	Sort     string `json:"sort" query:"sort" validate:"omitempty,oneof=transaction_date created_at otr installment_amount tenor"`
+	Order    string `json:"order" query:"order" validate:"omitempty,oneof=asc desc"`
+}
+
+// HoldRequest reserves the user limit for a checkout, the price is calculated the same way as TransactionRequest
+type HoldRequest struct {
+	OTR         money.Money `json:"otr" validate:"required"`
+	DownPayment money.Money `json:"down_payment" validate:"gte=0"` // Uang muka, tidak ikut dibiayai
+	AssetName   string      `json:"asset_name" validate:"required"`
+	Category    string      `json:"category" validate:"max=50"`
+	Tenor       int         `json:"tenor" validate:"required"`
+	Product     string      `json:"product" validate:"max=50"`
+	Reference   string      `json:"reference" validate:"max=255"` // Referensi checkout dari merchant
+
+	MerchantID   string `json:"merchant_id" validate:"omitempty,max=36"`
+	SalesChannel string `json:"sales_channel" validate:"omitempty,oneof=offline online app"`
 }
@@ -7,6 +7,12 @@
 
 package dto
 
+import (
+	"time"
+	"xyz/internal/model"
+	"xyz/pkg/money"
+)
+
 type UserRequest struct {
 	NIK             string  `json:"nik" validate:"required,max=16"`
 	FullName        string  `json:"full_name" validate:"required"`
@@ -17,3 +23,43 @@ type UserRequest struct {
 	Password        string  `json:"password"`
 	ConfirmPassword string  `json:"confirm_password"`
 }
+
+// TenorLimitsResponse is the credit line of the user and the tenor limits within it
+type TenorLimitsResponse struct {
+	CreditLine  *model.CreditLine     `json:"credit_line"`
+	TenorLimits []*TenorLimitResponse `json:"tenor_limits"`
+}
+
+// TenorLimitResponse is one tenor limit of the user.
+// AvailableAmount is what a transaction of the tenor can use, the smaller of the available credit line and the available tenor limit
+type TenorLimitResponse struct {
+	ID              string      `json:"id"`
+	TenorInMonths   int         `json:"tenor_in_months"`
+	LimitAmount     money.Money `json:"limit_amount"`
+	HeldAmount      money.Money `json:"held_amount"`
+	AvailableAmount money.Money `json:"available_amount"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+// LedgerListRequest is the query parameter of the limit ledger of a tenor
+type LedgerListRequest struct {
+	Pagination
+	Type string `json:"type" query:"type" validate:"omitempty,oneof=debit credit"`
+}
+
+// LimitAdjustmentRequest changes the credit line and tenor limit of the user by Amount.
+// Positive amount is a credit and negative amount is a debit
+type LimitAdjustmentRequest struct {
+	Amount money.Money `json:"amount"`
+	Reason string      `json:"reason" validate:"required,max=255"`
+}
+
+// LimitReconcileResult is the result of the limit reconciliation, Items are the credit lines and tenor limits with drift
+type LimitReconcileResult struct {
+	Scanned    int                          `json:"scanned"`
+	Mismatches int                          `json:"mismatches"`
+	Applied    int                          `json:"applied"`
+	Failed     int                          `json:"failed"`
+	Items      []*model.LimitReconciliation `json:"items"`
+}
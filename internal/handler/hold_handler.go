@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/dto"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+type HoldHandler struct {
+	holdSvc service.HoldService
+}
+
+func NewHoldHandler(repo repository.RepoRegistry) HoldHandler {
+	holdSvc := service.NewHoldService(repo.UserRepository, repo.TransactionRepository, repo.PricingRepository, repo.MerchantRepository)
+	return HoldHandler{
+		holdSvc: holdSvc,
+	}
+}
+
+func (h HoldHandler) Reserve(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "HoldHandler.Reserve")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.HoldRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	hold, _, err := h.holdSvc.Reserve(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, hold, fiber.StatusCreated, "Limit reserved successfully")
+}
+
+func (h HoldHandler) Get(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "HoldHandler.Get")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	hold, err := h.holdSvc.Get(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, hold, fiber.StatusOK, "Limit hold retrieved successfully")
+}
+
+func (h HoldHandler) Capture(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "HoldHandler.Capture")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	trx, _, err := h.holdSvc.Capture(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, trx, fiber.StatusCreated, "Transaction created successfully")
+}
+
+func (h HoldHandler) Release(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "HoldHandler.Release")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	hold, _, err := h.holdSvc.Release(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, hold, fiber.StatusOK, "Limit hold released successfully")
+}
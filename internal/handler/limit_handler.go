@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/dto"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+type LimitHandler struct {
+	limitSvc service.LimitService
+}
+
+func NewLimitHandler(repo repository.RepoRegistry) LimitHandler {
+	limitSvc := service.NewLimitService(repo.UserRepository, repo.TransactionRepository)
+	return LimitHandler{
+		limitSvc: limitSvc,
+	}
+}
+
+func (h LimitHandler) ListLedger(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "LimitHandler.ListLedger")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	tenor, err := c.ParamsInt("tenor")
+	if err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "params parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	var req dto.LedgerListRequest
+	if err = c.QueryParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "query parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	entries, meta, err := h.limitSvc.ListLedger(ctx, tenor, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, entries, meta, fiber.StatusOK, "Limit ledger retrieved successfully")
+}
+
+func (h LimitHandler) Adjust(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "LimitHandler.Adjust")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	tenor, err := c.ParamsInt("tenor")
+	if err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "params parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	var req dto.LimitAdjustmentRequest
+	if err = c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	entry, err := h.limitSvc.Adjust(ctx, c.Params("id"), tenor, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, entry, fiber.StatusCreated, "Limit adjusted successfully")
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/dto"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+type MerchantHandler struct {
+	merchantSvc service.MerchantService
+}
+
+func NewMerchantHandler(repo repository.RepoRegistry) MerchantHandler {
+	merchantSvc := service.NewMerchantService(repo.UserRepository, repo.MerchantRepository)
+	return MerchantHandler{
+		merchantSvc: merchantSvc,
+	}
+}
+
+func (h MerchantHandler) Create(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "MerchantHandler.Create")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.MerchantRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	merchant, err := h.merchantSvc.Create(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, merchant, fiber.StatusCreated, "Merchant created successfully")
+}
+
+func (h MerchantHandler) Update(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "MerchantHandler.Update")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.MerchantRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	merchant, err := h.merchantSvc.Update(ctx, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, merchant, fiber.StatusOK, "Merchant updated successfully")
+}
+
+func (h MerchantHandler) Delete(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "MerchantHandler.Delete")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	merchant, err := h.merchantSvc.Delete(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, merchant, fiber.StatusOK, "Merchant deactivated successfully")
+}
+
+func (h MerchantHandler) Get(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "MerchantHandler.Get")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	merchant, err := h.merchantSvc.Get(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, merchant, fiber.StatusOK, "Merchant retrieved successfully")
+}
+
+func (h MerchantHandler) List(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "MerchantHandler.List")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.MerchantListRequest
+	if err := c.QueryParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "query parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	merchants, meta, err := h.merchantSvc.List(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, merchants, meta, fiber.StatusOK, "Merchants retrieved successfully")
+}
+
+func (h MerchantHandler) Report(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "MerchantHandler.Report")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.MerchantReportRequest
+	if err := c.QueryParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "query parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	reports, err := h.merchantSvc.Report(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, reports, fiber.StatusOK, "Merchant report retrieved successfully")
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/dto"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+type PaymentHandler struct {
+	paymentSvc    service.PaymentService
+	settlementSvc service.SettlementService
+}
+
+func NewPaymentHandler(repo repository.RepoRegistry) PaymentHandler {
+	paymentSvc := service.NewPaymentService(repo.UserRepository, repo.TransactionRepository, repo.PaymentRepository)
+	settlementSvc := service.NewSettlementService(repo.UserRepository, repo.TransactionRepository, repo.PaymentRepository)
+	return PaymentHandler{
+		paymentSvc:    paymentSvc,
+		settlementSvc: settlementSvc,
+	}
+}
+
+func (h PaymentHandler) Create(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PaymentHandler.Create")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.PaymentRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	payment, err := h.paymentSvc.Create(ctx, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, payment, fiber.StatusCreated, "Payment recorded successfully")
+}
+
+func (h PaymentHandler) SettlementQuote(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PaymentHandler.SettlementQuote")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	quote, err := h.settlementSvc.Quote(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, quote, fiber.StatusOK, "Settlement quote created successfully")
+}
+
+func (h PaymentHandler) Settle(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PaymentHandler.Settle")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.SettleRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	payment, err := h.settlementSvc.Settle(ctx, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, payment, fiber.StatusCreated, "Transaction settled successfully")
+}
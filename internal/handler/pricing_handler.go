@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/dto"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+type PricingHandler struct {
+	pricingSvc service.PricingService
+}
+
+func NewPricingHandler(repo repository.RepoRegistry) PricingHandler {
+	pricingSvc := service.NewPricingService(repo.UserRepository, repo.PricingRepository)
+	return PricingHandler{
+		pricingSvc: pricingSvc,
+	}
+}
+
+func (h PricingHandler) CreateVersion(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PricingHandler.CreateVersion")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.PricingVersionRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	version, err := h.pricingSvc.CreateVersion(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, version, fiber.StatusCreated, "Pricing version created successfully")
+}
+
+func (h PricingHandler) ListVersions(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PricingHandler.ListVersions")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.PricingListRequest
+	if err := c.QueryParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "query parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	versions, err := h.pricingSvc.ListVersions(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, versions, fiber.StatusOK, "Pricing versions retrieved successfully")
+}
+
+func (h PricingHandler) ListCategories(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PricingHandler.ListCategories")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	categories, err := h.pricingSvc.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, categories, fiber.StatusOK, "Asset categories retrieved successfully")
+}
+
+func (h PricingHandler) CreateCategory(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PricingHandler.CreateCategory")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.AssetCategoryRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	category, err := h.pricingSvc.CreateCategory(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, category, fiber.StatusCreated, "Asset category created successfully")
+}
+
+func (h PricingHandler) UpdateCategory(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "PricingHandler.UpdateCategory")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.AssetCategoryRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	category, err := h.pricingSvc.UpdateCategory(ctx, c.Params("code"), req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, category, fiber.StatusOK, "Asset category updated successfully")
+}
@@ -21,7 +21,7 @@ type TransactionHandler struct {
 }
 
 func NewTransactionHandler(repo repository.RepoRegistry) TransactionHandler {
-	userSvc := service.NewTransactionService(repo.UserRepository, repo.TransactionRepository)
+	userSvc := service.NewTransactionService(repo.UserRepository, repo.TransactionRepository, repo.PricingRepository, repo.MerchantRepository)
 	return TransactionHandler{
 		transactionSvc: userSvc,
 	}
@@ -46,3 +46,121 @@ func (h TransactionHandler) Create(c *fiber.Ctx) error {
 
 	return response.Success(c, user, fiber.StatusCreated, "Transaction created successfully")
 }
+
+func (h TransactionHandler) Simulate(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.Simulate")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.SimulationRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	simulations, err := h.transactionSvc.Simulate(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, simulations, fiber.StatusOK, "Transaction simulated successfully")
+}
+
+func (h TransactionHandler) Approve(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.Approve")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	trx, err := h.transactionSvc.Approve(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, trx, fiber.StatusOK, "Transaction approved successfully")
+}
+
+func (h TransactionHandler) Reject(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.Reject")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	var req dto.RejectTransactionRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		span.RecordErrorHelper(response.ErrorServer("", err), "body parser")
+		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+	}
+
+	trx, _, err := h.transactionSvc.Reject(ctx, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, trx, fiber.StatusOK, "Transaction rejected successfully")
+}
+
+func (h TransactionHandler) Cancel(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.Cancel")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	trx, _, err := h.transactionSvc.Cancel(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, trx, fiber.StatusOK, "Transaction cancelled successfully")
+}
+
+func (h TransactionHandler) Get(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.Get")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	trx, err := h.transactionSvc.Get(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, trx, fiber.StatusOK, "Transaction retrieved successfully")
+}
+
+func (h TransactionHandler) GetByContractNumber(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.GetByContractNumber")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	trx, err := h.transactionSvc.GetByContractNumber(ctx, c.Params("contract_number"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, trx, fiber.StatusOK, "Transaction retrieved successfully")
+}
+
+func (h TransactionHandler) ListInstallments(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.ListInstallments")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	installments, err := h.transactionSvc.GetInstallments(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, installments, fiber.StatusOK, "Installments retrieved successfully")
+}
+
+func (h TransactionHandler) ListHistory(c *fiber.Ctx) error {
+	ctx, span := otel.StartSpan(c.UserContext(), "TransactionHandler.ListHistory")
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	histories, err := h.transactionSvc.GetHistory(ctx, c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, histories, fiber.StatusOK, "Transaction history retrieved successfully")
+}
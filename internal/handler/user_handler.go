@@ -96,7 +96,7 @@ func (h UserHandler) ListTransactions(c *fiber.Ctx) error {
 	defer span.End()
 	c.SetUserContext(ctx)
 
-	var req dto.Pagination
+	var req dto.TransactionListRequest
 	if err := c.QueryParser(&req); err != nil {
 		span.RecordErrorHelper(response.ErrorServer("", err), "query parser")
 		return response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
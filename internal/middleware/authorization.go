@@ -38,6 +38,9 @@ func authorization(c *fiber.Ctx) error {
 	}
 
 	authSplit := strings.Split(authHeader, " ")
+	if len(authSplit) != 2 {
+		return response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgInvalidToken)
+	}
 	authType := strings.ToLower(authSplit[0])
 	authToken := authSplit[1]
 
@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gofiber/fiber/v2/utils"
+	fiberRedis "github.com/gofiber/storage/redis/v3"
+	"github.com/spf13/viper"
+	"time"
+	"xyz/pkg/config"
+	"xyz/pkg/helper"
+	"xyz/pkg/response"
+)
+
+const (
+	IdempotencyKeyHeader      = "X-Idempotency-Key"
+	IdempotencyReplayedHeader = "X-Idempotency-Replayed"
+
+	// defaultIdempotencyLifetime is used when `idempotency.lifetime` is not configured
+	defaultIdempotencyLifetime = 30 * time.Minute
+	// idempotencyLockLifetime is the lifetime of in progress marker, so the key is released if the replica crashes
+	idempotencyLockLifetime = time.Minute
+	// maxIdempotencyKeyLength is the maximum length of the idempotency key header
+	maxIdempotencyKeyLength = 128
+)
+
+// idempotencyRecord is the saved response of an idempotency key
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	InProgress  bool   `json:"in_progress,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// idempotencyStorage is fiber.Storage that can claim a key atomically
+type idempotencyStorage interface {
+	fiber.Storage
+	// SetNX saves val only if key does not exist yet. It returns false if the key already exists
+	SetNX(key string, val []byte, exp time.Duration) (bool, error)
+}
+
+// redisIdempotencyStorage claims the key with redis SET NX
+type redisIdempotencyStorage struct {
+	*fiberRedis.Storage
+}
+
+func (s redisIdempotencyStorage) SetNX(key string, val []byte, exp time.Duration) (bool, error) {
+	return s.Conn().SetNX(context.Background(), key, val, exp).Result()
+}
+
+// Idempotency is middleware to replay the response of unsafe request that is sent again with the same `X-Idempotency-Key` header.
+//
+// The response is saved in redis, so it works across replicas. The key is claimed with SET NX before the request is handled,
+// so only one replica handles the same key at a time. Keys are scoped per authenticated user (or per IP),
+// and the fingerprint of method, path and body is saved with the response. Reusing a key with a different request returns 422.
+// Error responses are not saved, so the request can be retried
+func Idempotency(fiberStorage *fiberRedis.Storage) fiber.Handler {
+	return idempotencyHandler(redisIdempotencyStorage{fiberStorage})
+}
+
+func idempotencyHandler(fiberStorage idempotencyStorage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if fiber.IsMethodSafe(c.Method()) {
+			return c.Next()
+		}
+
+		idempotencyKey := utils.CopyString(c.Get(IdempotencyKeyHeader))
+		if idempotencyKey == "" {
+			return c.Next()
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return response.ErrorParameter(response.ErrBadRequest, response.MsgIdempotencyKey)
+		}
+
+		key := config.GetRedisKey("idempotency:%s:%s", idempotencyScope(c), idempotencyKey)
+		fingerprint := requestFingerprint(c)
+
+		// fast path, the response is already saved
+		if ok, err := replayIdempotency(c, fiberStorage, key, fingerprint); ok || err != nil {
+			return err
+		}
+
+		// claim the key atomically, the in progress marker expires if the replica crashes
+		val, err := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, InProgress: true})
+		if err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		claimed, err := fiberStorage.SetNX(key, val, idempotencyLockLifetime)
+		if err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		if !claimed {
+			// another request claimed the key first, replay its response if it is done already
+			if ok, errReplay := replayIdempotency(c, fiberStorage, key, fingerprint); ok || errReplay != nil {
+				return errReplay
+			}
+			// the key is released or expired after the claim failed, it still counts as in progress
+			return response.ErrorParameter(response.ErrIdempotencyLocked, response.MsgIdempotencyLocked, fiber.StatusConflict)
+		}
+
+		if err := c.Next(); err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			if errDelete := fiberStorage.Delete(key); errDelete != nil {
+				log.Errorf("[IDEMPOTENCY] failed to release key %q: %v", key, errDelete)
+			}
+			return err
+		}
+
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  c.Response().StatusCode(),
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        utils.CopyBytes(c.Response().Body()),
+		}
+		if err := saveIdempotency(fiberStorage, key, record, idempotencyLifetime()); err != nil {
+			log.Errorf("[IDEMPOTENCY] failed to save response of key %q: %v", key, err)
+		}
+		return nil
+	}
+}
+
+// replayIdempotency writes the saved response of key. It returns true if the response is written
+func replayIdempotency(c *fiber.Ctx, fiberStorage fiber.Storage, key, fingerprint string) (bool, error) {
+	val, err := fiberStorage.Get(key)
+	if err != nil {
+		return false, response.ErrorServer(response.MsgInternalServer, err)
+	}
+	if val == nil {
+		return false, nil
+	}
+
+	var record idempotencyRecord
+	if err = json.Unmarshal(val, &record); err != nil {
+		return false, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	if record.Fingerprint != fingerprint {
+		return false, response.ErrorParameter(response.ErrIdempotencyReused, response.MsgIdempotencyReused, fiber.StatusUnprocessableEntity)
+	}
+	if record.InProgress {
+		return false, response.ErrorParameter(response.ErrIdempotencyLocked, response.MsgIdempotencyLocked, fiber.StatusConflict)
+	}
+
+	c.Set(IdempotencyReplayedHeader, "true")
+	if record.ContentType != "" {
+		c.Set(fiber.HeaderContentType, record.ContentType)
+	}
+	return true, c.Status(record.StatusCode).Send(record.Body)
+}
+
+func saveIdempotency(fiberStorage fiber.Storage, key string, record idempotencyRecord, lifetime time.Duration) error {
+	val, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return fiberStorage.Set(key, val, lifetime)
+}
+
+// idempotencyScope returns the owner of the idempotency key, the authenticated user or the client IP
+func idempotencyScope(c *fiber.Ctx) string {
+	if c.Get("authorization") != "" && authorization(c) == nil {
+		if userid := helper.GetValueContext(c.UserContext(), "userid", ""); userid != "" {
+			return "user:" + userid
+		}
+	}
+	return "ip:" + helper.GetIP(c)
+}
+
+// requestFingerprint is the hash of method, path and body of the request
+func requestFingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(c.Path()))
+	h.Write([]byte{'\n'})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyLifetime returns how long the response is saved, configured with `idempotency.lifetime`
+func idempotencyLifetime() time.Duration {
+	if lifetime := viper.GetDuration("idempotency.lifetime"); lifetime > 0 {
+		return lifetime
+	}
+	return defaultIdempotencyLifetime
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"xyz/pkg/encrypt"
+	"xyz/pkg/response"
+)
+
+// memoryStorage is idempotencyStorage for test, shared by the apps like Redis is shared by the replicas
+type memoryStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *memoryStorage) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key], nil
+}
+
+func (s *memoryStorage) Set(key string, val []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = val
+	return nil
+}
+
+func (s *memoryStorage) SetNX(key string, val []byte, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok {
+		return false, nil
+	}
+	s.data[key] = val
+	return true, nil
+}
+
+func (s *memoryStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func (s *memoryStorage) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = map[string][]byte{}
+	return nil
+}
+
+func (s *memoryStorage) Close() error {
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	viper.Set("app_env", "test")
+	viper.Set("secret.jwt", "test-secret")
+	defer viper.Set("secret.jwt", "")
+
+	storage := &memoryStorage{data: map[string][]byte{}}
+	created := 0
+
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{
+			ErrorHandler: func(c *fiber.Ctx, err error) error {
+				var e response.ErrorResponse
+				if errors.As(err, &e) {
+					return e.Response(c)
+				}
+				return response.ErrorServer(response.MsgInternalServer, err).Response(c)
+			},
+		})
+		app.Use(idempotencyHandler(storage))
+		app.Post("/transactions", func(c *fiber.Ctx) error {
+			if strings.Contains(string(c.Body()), "fail") {
+				return response.ErrorServer(response.MsgInternalServer, errors.New("database error"))
+			}
+			if strings.Contains(string(c.Body()), "slow") {
+				time.Sleep(100 * time.Millisecond)
+			}
+			created++
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{"created": created})
+		})
+		return app
+	}
+	// two replicas behind load balancer
+	replicas := []*fiber.App{newApp(), newApp()}
+
+	token := func(userid string) string {
+		tokenString, err := encrypt.GenerateJWTToken(jwt.RegisteredClaims{
+			Subject:   userid,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+		assert.NoError(t, err)
+		return "Bearer " + tokenString
+	}
+
+	send := func(app *fiber.App, key, auth, body string) (int, string, string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/transactions", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if auth != "" {
+			req.Header.Set(fiber.HeaderAuthorization, auth)
+		}
+		res, err := app.Test(req)
+		assert.NoError(t, err)
+		b, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(b), res.Header.Get(IdempotencyReplayedHeader)
+	}
+
+	userA, userB := token("user-a"), token("user-b")
+	body := `{"otr":800000,"tenor":3}`
+
+	status, resBody, replayed := send(replicas[0], "key-1", userA, body)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, `{"created":1}`, resBody)
+	assert.Empty(t, replayed)
+
+	t.Run("Retry on another replica is replayed", func(t *testing.T) {
+		status, resBody, replayed := send(replicas[1], "key-1", userA, body)
+		assert.Equal(t, fiber.StatusCreated, status)
+		assert.Equal(t, `{"created":1}`, resBody)
+		assert.Equal(t, "true", replayed)
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("Same key with different body", func(t *testing.T) {
+		status, resBody, _ := send(replicas[1], "key-1", userA, `{"otr":900000,"tenor":3}`)
+		assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+		assert.Contains(t, resBody, response.ErrIdempotencyReused)
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("Same key of another user", func(t *testing.T) {
+		status, resBody, replayed := send(replicas[0], "key-1", userB, body)
+		assert.Equal(t, fiber.StatusCreated, status)
+		assert.Equal(t, `{"created":2}`, resBody)
+		assert.Empty(t, replayed)
+	})
+
+	t.Run("Without key", func(t *testing.T) {
+		send(replicas[0], "", userA, body)
+		send(replicas[0], "", userA, body)
+		assert.Equal(t, 4, created)
+	})
+
+	t.Run("Error response is not saved", func(t *testing.T) {
+		status, _, _ := send(replicas[0], "key-2", userA, `{"fail":true}`)
+		assert.Equal(t, fiber.StatusInternalServerError, status)
+		status, _, replayed := send(replicas[1], "key-2", userA, body)
+		assert.Equal(t, fiber.StatusCreated, status)
+		assert.Empty(t, replayed)
+	})
+
+	t.Run("Request still in progress on another replica", func(t *testing.T) {
+		sum := sha256.Sum256([]byte("POST\n/transactions\n" + body))
+		_ = storage.Set("xyz:idempotency:user:user-a:key-3", []byte(`{"fingerprint":"`+hex.EncodeToString(sum[:])+`","in_progress":true}`), time.Minute)
+
+		status, resBody, _ := send(replicas[0], "key-3", userA, body)
+		assert.Equal(t, fiber.StatusConflict, status)
+		assert.Contains(t, resBody, response.ErrIdempotencyLocked)
+	})
+
+	t.Run("Same key on both replicas at the same time", func(t *testing.T) {
+		before := created
+		slowBody := `{"otr":800000,"tenor":3,"slow":true}`
+
+		var wg sync.WaitGroup
+		statuses := make([]int, len(replicas))
+		for i, app := range replicas {
+			wg.Add(1)
+			go func(i int, app *fiber.App) {
+				defer wg.Done()
+				statuses[i], _, _ = send(app, "key-4", userA, slowBody)
+			}(i, app)
+		}
+		wg.Wait()
+
+		// only one replica wins the claim, the other sees the request in progress
+		assert.ElementsMatch(t, []int{fiber.StatusCreated, fiber.StatusConflict}, statuses)
+		assert.Equal(t, before+1, created)
+	})
+
+	t.Run("Key too long", func(t *testing.T) {
+		status, _, _ := send(replicas[0], strings.Repeat("k", maxIdempotencyKeyLength+1), userA, body)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"xyz/pkg/money"
+)
+
+// AssetCategory is the financing rule of a kind of asset, e.g. white goods, motorcycle or car
+type AssetCategory struct {
+	ID                    string      `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	Code                  string      `gorm:"column:code;type:varchar(50);not null;unique" json:"code"`
+	Name                  string      `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Tenors                Tenors      `gorm:"column:tenors;type:json;not null" json:"tenors"`
+	MinOTR                money.Money `gorm:"column:min_otr;type:decimal(15,2);not null" json:"min_otr"`
+	MaxOTR                money.Money `gorm:"column:max_otr;type:decimal(15,2);not null" json:"max_otr"` // 0 jika tidak ada maksimal
+	MinDownPaymentPercent float64     `gorm:"column:min_down_payment_percent;type:decimal(5,2);not null" json:"min_down_payment_percent"`
+	Active                bool        `gorm:"column:active;type:boolean;not null" json:"active"`
+	CreatedAt             time.Time   `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt             time.Time   `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+func (AssetCategory) TableName() string {
+	return "asset_categories"
+}
+
+// Tenors list of tenor in months, saved as JSON array
+type Tenors []int
+
+// Contains check if tenor is in the list
+func (t Tenors) Contains(tenor int) bool {
+	return slices.Contains(t, tenor)
+}
+
+// String returns comma separated tenors, e.g. `6, 12, 24`
+func (t Tenors) String() string {
+	s := make([]string, len(t))
+	for i, tenor := range t {
+		s[i] = strconv.Itoa(tenor)
+	}
+	return strings.Join(s, ", ")
+}
+
+func (t Tenors) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]int(t))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (t *Tenors) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Tenors", src)
+	}
+	return json.Unmarshal(b, (*[]int)(t))
+}
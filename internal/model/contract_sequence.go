@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import "time"
+
+// ContractSequence is the last running number of contract numbers with the same key (period)
+type ContractSequence struct {
+	SequenceKey string    `gorm:"column:sequence_key;type:varchar(191);primarykey" json:"sequence_key"`
+	LastNumber  int64     `gorm:"column:last_number;type:bigint;not null" json:"last_number"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+func (ContractSequence) TableName() string {
+	return "contract_sequences"
+}
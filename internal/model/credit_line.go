@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+	"xyz/pkg/money"
+)
+
+// CreditLine is the master limit of the user. Every tenor limit is a cap within the credit line,
+// so a transaction is debited from both the credit line and the tenor limit
+type CreditLine struct {
+	ID          string      `gorm:"column:id;primaryKey;type:uuid" json:"id"`
+	UserID      string      `gorm:"column:user_id;type:uuid;not null" json:"-"`
+	LimitAmount money.Money `gorm:"column:limit_amount;type:decimal(15,2);not null" json:"limit_amount"`
+	HeldAmount  money.Money `gorm:"column:held_amount;->;-:migration" json:"held_amount"`
+	// GrantedAmount is the credit line given to the user, it is only changed by admin adjustments
+	GrantedAmount money.Money `gorm:"column:granted_amount;type:decimal(15,2);not null" json:"-"`
+	// Version is incremented on every limit change, used by the optimistic limit strategy
+	Version int64 `gorm:"column:version;type:int;not null" json:"-"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+func (c *CreditLine) TableName() string {
+	return "user_credit_lines"
+}
+
+// Available is the credit line that can be used, the limit amount minus the active limit holds of all tenors (HeldAmount).
+// HeldAmount is not saved, it is filled by the repository
+func (c CreditLine) Available() money.Money {
+	return c.LimitAmount.Sub(c.HeldAmount)
+}
+
+func (c CreditLine) MarshalJSON() ([]byte, error) {
+	type alias CreditLine
+	return json.Marshal(struct {
+		alias
+		AvailableAmount money.Money `json:"available_amount"`
+	}{alias(c), c.Available()})
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"time"
+	"xyz/pkg/money"
+)
+
+const (
+	InstallmentUNPAID  = "unpaid"
+	InstallmentPARTIAL = "partial"
+	InstallmentPAID    = "paid"
+	// InstallmentSETTLED is installment that closed by early settlement
+	InstallmentSETTLED = "settled"
+)
+
+type Installment struct {
+	ID              string        `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	TransactionID   string        `gorm:"column:transaction_id;type:uuid;not null" json:"transaction_id"`
+	Sequence        int           `gorm:"column:sequence;type:int;not null" json:"sequence"`
+	DueDate         time.Time     `gorm:"column:due_date;type:date;not null" json:"due_date"`
+	PrincipalAmount money.Money   `gorm:"column:principal_amount;type:decimal(15,2);not null" json:"principal_amount"`
+	InterestAmount  money.Money   `gorm:"column:interest_amount;type:decimal(15,2);not null" json:"interest_amount"`
+	FeeAmount       money.Money   `gorm:"column:fee_amount;type:decimal(15,2);not null" json:"fee_amount"`
+	AmountDue       money.Money   `gorm:"column:amount_due;type:decimal(15,2);not null" json:"amount_due"`
+	PenaltyAmount   money.Money   `gorm:"column:penalty_amount;type:decimal(15,2);not null" json:"penalty_amount"`
+	PrincipalPaid   money.Money   `gorm:"column:principal_paid;type:decimal(15,2);not null" json:"principal_paid"`
+	InterestPaid    money.Money   `gorm:"column:interest_paid;type:decimal(15,2);not null" json:"interest_paid"`
+	FeePaid         money.Money   `gorm:"column:fee_paid;type:decimal(15,2);not null" json:"fee_paid"`
+	PenaltyPaid     money.Money   `gorm:"column:penalty_paid;type:decimal(15,2);not null" json:"penalty_paid"`
+	AmountPaid      money.Money   `gorm:"column:amount_paid;type:decimal(15,2);not null" json:"amount_paid"`
+	Status          string        `gorm:"column:status;type:enum('unpaid', 'partial', 'paid', 'settled');not null" json:"status"`
+	PaidAt          nullable.Time `gorm:"column:paid_at;type:timestamp" json:"paid_at"`
+	CreatedAt       time.Time     `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt       time.Time     `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+func (Installment) TableName() string {
+	return "installments"
+}
+
+// Outstanding is the remaining amount that has not been paid, including late payment penalty
+func (i Installment) Outstanding() money.Money {
+	return i.AmountDue.Add(i.PenaltyAmount).Sub(i.AmountPaid)
+}
+
+// OutstandingDue is the remaining amount that has not been paid, excluding late payment penalty
+func (i Installment) OutstandingDue() money.Money {
+	return i.AmountDue.Sub(i.AmountPaid.Sub(i.PenaltyPaid))
+}
+
+// IsClosed check if the installment doesn't need to be paid anymore
+func (i Installment) IsClosed() bool {
+	return i.Status == InstallmentPAID || i.Status == InstallmentSETTLED
+}
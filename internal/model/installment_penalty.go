@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"time"
+	"xyz/pkg/money"
+)
+
+// InstallmentPenalty is ledger of daily late payment fee (denda) of an installment
+type InstallmentPenalty struct {
+	ID            string      `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	InstallmentID string      `gorm:"column:installment_id;type:uuid;not null" json:"installment_id"`
+	TransactionID string      `gorm:"column:transaction_id;type:uuid;not null" json:"transaction_id"`
+	AccrualDate   time.Time   `gorm:"column:accrual_date;type:date;not null" json:"accrual_date"`
+	DaysOverdue   int         `gorm:"column:days_overdue;type:int;not null" json:"days_overdue"`
+	BaseAmount    money.Money `gorm:"column:base_amount;type:decimal(15,2);not null" json:"base_amount"`
+	Amount        money.Money `gorm:"column:amount;type:decimal(15,2);not null" json:"amount"`
+	CreatedAt     time.Time   `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+}
+
+func (InstallmentPenalty) TableName() string {
+	return "installment_penalties"
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"time"
+	"xyz/pkg/money"
+)
+
+const (
+	HoldACTIVE   = "active"
+	HoldCAPTURED = "captured"
+	HoldRELEASED = "released"
+	HoldEXPIRED  = "expired"
+)
+
+// LimitHold is a reservation of the user tenor limit, e.g. on merchant checkout.
+//
+// Active hold reduces the available limit until it is captured into a transaction, released or expired.
+// The price is calculated when the hold is created, so the captured transaction has the same amount
+type LimitHold struct {
+	ID                string          `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	UserID            string          `gorm:"column:user_id;type:uuid;not null" json:"user_id"`
+	Tenor             int             `gorm:"column:tenor;type:int;not null" json:"tenor"`
+	OTR               money.Money     `gorm:"column:otr;type:decimal(15,2);not null" json:"otr"`
+	DownPayment       money.Money     `gorm:"column:down_payment;type:decimal(15,2);not null" json:"down_payment"`
+	AdminFee          money.Money     `gorm:"column:admin_fee;type:decimal(15,2);not null" json:"admin_fee"`
+	InstallmentAmount money.Money     `gorm:"column:installment_amount;type:decimal(15,2);not null" json:"installment_amount"`
+	InterestAmount    money.Money     `gorm:"column:interest_amount;type:decimal(15,2);not null" json:"interest_amount"`
+	Amount            money.Money     `gorm:"column:amount;type:decimal(15,2);not null" json:"amount"`
+	AssetName         string          `gorm:"column:asset_name;type:varchar(255);not null" json:"asset_name"`
+	AssetCategory     nullable.String `gorm:"column:asset_category;type:varchar(50)" json:"asset_category"`
+	ProductCode       string          `gorm:"column:product_code;type:varchar(50);not null" json:"product_code"`
+	PricingVersionID  nullable.String `gorm:"column:pricing_version_id;type:uuid" json:"pricing_version_id"`
+	MerchantID        nullable.String `gorm:"column:merchant_id;type:uuid" json:"merchant_id"`
+	SalesChannel      nullable.String `gorm:"column:sales_channel;type:enum('offline', 'online', 'app')" json:"sales_channel"`
+	Reference         nullable.String `gorm:"column:reference;type:varchar(255)" json:"reference"`
+	Status            string          `gorm:"column:status;type:enum('active', 'captured', 'released', 'expired');not null" json:"status"`
+	TransactionID     nullable.String `gorm:"column:transaction_id;type:uuid" json:"transaction_id"`
+	ExpiresAt         time.Time       `gorm:"column:expires_at;type:timestamp;not null" json:"expires_at"`
+	CapturedAt        nullable.Time   `gorm:"column:captured_at;type:timestamp" json:"captured_at"`
+	ReleasedAt        nullable.Time   `gorm:"column:released_at;type:timestamp" json:"released_at"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+func (LimitHold) TableName() string {
+	return "limit_holds"
+}
+
+// IsExpired check if the active hold has passed its expiry time
+func (h LimitHold) IsExpired(now time.Time) bool {
+	return h.Status == HoldEXPIRED || (h.Status == HoldACTIVE && !now.Before(h.ExpiresAt))
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"time"
+	"xyz/pkg/money"
+)
+
+const (
+	LedgerDEBIT  = "debit"
+	LedgerCREDIT = "credit"
+)
+
+const (
+	// LedgerSourceOPENING is the limit amount when the ledger is created, the source is the tenor limit
+	LedgerSourceOPENING = "opening"
+	// LedgerSourceTRANSACTION is a new transaction or captured limit hold, the source is the transaction
+	LedgerSourceTRANSACTION = "transaction"
+	// LedgerSourceREJECTION is a rejected transaction, the source is the transaction
+	LedgerSourceREJECTION = "rejection"
+	// LedgerSourceCANCELLATION is a cancelled transaction, the source is the transaction
+	LedgerSourceCANCELLATION = "cancellation"
+	// LedgerSourcePAYMENT is a repayment or settlement, the source is the payment
+	LedgerSourcePAYMENT = "payment"
+	// LedgerSourceADJUSTMENT is a manual change by admin, the source is the admin user
+	LedgerSourceADJUSTMENT = "adjustment"
+	// LedgerSourceRECONCILIATION is a correction of limit drift by the limit reconciliation, the source is the corrected tenor limit or credit line
+	LedgerSourceRECONCILIATION = "reconciliation"
+)
+
+// LimitLedger is one change of a tenor limit. Every change is applied to the credit line with the same amount,
+// the balances after the change are saved so the history can be read without replaying the ledger.
+//
+// A reconciliation of the credit line only has no TenorLimitID and Tenor 0, its BalanceAfter is the credit line balance.
+//
+// The ledger is append-only, entries are never updated or deleted
+type LimitLedger struct {
+	ID                     string          `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	UserID                 string          `gorm:"column:user_id;type:uuid;not null" json:"-"`
+	TenorLimitID           nullable.String `gorm:"column:tenor_limit_id;type:uuid" json:"tenor_limit_id"`
+	Tenor                  int             `gorm:"column:tenor;type:int;not null" json:"tenor"`
+	Type                   string          `gorm:"column:type;type:varchar(10);not null" json:"type"`
+	SourceType             string          `gorm:"column:source_type;type:varchar(20);not null" json:"source_type"`
+	SourceID               string          `gorm:"column:source_id;type:uuid;not null" json:"source_id"`
+	Amount                 money.Money     `gorm:"column:amount;type:decimal(15,2);not null" json:"amount"`
+	BalanceAfter           money.Money     `gorm:"column:balance_after;type:decimal(15,2);not null" json:"balance_after"`
+	CreditLineBalanceAfter money.Money     `gorm:"column:credit_line_balance_after;type:decimal(15,2);not null" json:"credit_line_balance_after"`
+	Reason                 nullable.String `gorm:"column:reason;type:varchar(255)" json:"reason"`
+	CreatedAt              time.Time       `gorm:"column:created_at;type:timestamp(6);not null" json:"created_at"`
+}
+
+func (l *LimitLedger) TableName() string {
+	return "limit_ledger"
+}
+
+const (
+	ReconcileCREDITLINE = "credit_line"
+	ReconcileTENORLIMIT = "tenor_limit"
+)
+
+// LimitReconciliation compares one tenor limit or credit line with the records that use it.
+// The available amount is expected to be the granted limit minus the outstanding amount:
+// the transactions that are not rejected, cancelled or paid back (UnpaidAmount) and the active limit holds (HeldAmount).
+// Active limit holds are not deducted from the limit amount, so Drift is also the drift of the limit amount
+type LimitReconciliation struct {
+	LimitType         string      `gorm:"column:limit_type" json:"limit_type"` // ReconcileCREDITLINE or ReconcileTENORLIMIT
+	LimitID           string      `gorm:"column:limit_id" json:"limit_id"`
+	UserID            string      `gorm:"column:user_id" json:"user_id"`
+	Tenor             int         `gorm:"column:tenor" json:"tenor"` // 0 for credit line
+	LimitAmount       money.Money `gorm:"column:limit_amount" json:"limit_amount"`
+	HeldAmount        money.Money `gorm:"column:held_amount" json:"held_amount"`
+	GrantedAmount     money.Money `gorm:"column:granted_amount" json:"granted_amount"`
+	UnpaidAmount      money.Money `gorm:"column:unpaid_amount" json:"unpaid_amount"`
+	OutstandingAmount money.Money `gorm:"-" json:"outstanding_amount"`
+	AvailableAmount   money.Money `gorm:"-" json:"available_amount"`
+	ExpectedAmount    money.Money `gorm:"-" json:"expected_amount"` // Expected available amount
+	Drift             money.Money `gorm:"-" json:"drift"`           // Available amount minus expected amount
+	Applied           bool        `gorm:"-" json:"applied"`
+}
+
+// Calculate sets OutstandingAmount, AvailableAmount, ExpectedAmount and Drift
+func (r *LimitReconciliation) Calculate() {
+	r.OutstandingAmount = r.UnpaidAmount.Add(r.HeldAmount)
+	r.AvailableAmount = r.LimitAmount.Sub(r.HeldAmount)
+	r.ExpectedAmount = r.GrantedAmount.Sub(r.OutstandingAmount)
+	r.Drift = r.AvailableAmount.Sub(r.ExpectedAmount)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"math"
+	"time"
+	"xyz/pkg/money"
+)
+
+const (
+	MerchantDEALER = "dealer"
+	MerchantSTORE  = "store"
+	MerchantONLINE = "online"
+)
+
+const (
+	// ChannelOFFLINE purchase at the merchant outlet or dealer
+	ChannelOFFLINE = "offline"
+	// ChannelONLINE purchase at the merchant online store or marketplace
+	ChannelONLINE = "online"
+	// ChannelAPP purchase from our own app
+	ChannelAPP = "app"
+)
+
+// Merchant is the dealer or store where the financed asset is bought
+type Merchant struct {
+	ID        string          `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	Code      string          `gorm:"column:code;type:varchar(50);not null;unique" json:"code"`
+	Name      string          `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Type      string          `gorm:"column:type;type:enum('dealer', 'store', 'online');not null" json:"type"`
+	Address   nullable.String `gorm:"column:address;type:varchar(255)" json:"address"`
+	Active    bool            `gorm:"column:active;type:boolean;not null" json:"active"`
+	CreatedAt time.Time       `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+func (Merchant) TableName() string {
+	return "merchants"
+}
+
+// MerchantReport is the transaction volume and approval rate of one merchant.
+//
+// Approved transactions include settled transactions, pending and cancelled transactions are not reviewed
+type MerchantReport struct {
+	MerchantID       string      `gorm:"column:merchant_id" json:"merchant_id"`
+	Code             string      `gorm:"column:code" json:"code"`
+	Name             string      `gorm:"column:name" json:"name"`
+	TransactionCount int64       `gorm:"column:transaction_count" json:"transaction_count"`
+	ApprovedCount    int64       `gorm:"column:approved_count" json:"approved_count"`
+	RejectedCount    int64       `gorm:"column:rejected_count" json:"rejected_count"`
+	Volume           money.Money `gorm:"column:volume" json:"volume"` // Total OTR of approved transactions
+	ApprovalRate     float64     `gorm:"-" json:"approval_rate"`      // Persen approved dari transaksi yang sudah direview
+}
+
+// CalculateApprovalRate sets ApprovalRate in percent with 2 decimals, zero if no transaction is reviewed
+func (r *MerchantReport) CalculateApprovalRate() {
+	reviewed := r.ApprovedCount + r.RejectedCount
+	if reviewed == 0 {
+		r.ApprovalRate = 0
+		return
+	}
+	r.ApprovalRate = math.Round(float64(r.ApprovedCount)*10000/float64(reviewed)) / 100
+}
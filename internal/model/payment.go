@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"time"
+	"xyz/pkg/money"
+)
+
+type Payment struct {
+	ID            string      `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	TransactionID string      `gorm:"column:transaction_id;type:uuid;not null" json:"transaction_id"`
+	UserID        string      `gorm:"column:user_id;type:uuid;not null" json:"user_id"`
+	Amount        money.Money `gorm:"column:amount;type:decimal(15,2);not null" json:"amount"`
+	AppliedAmount money.Money `gorm:"column:applied_amount;type:decimal(15,2);not null" json:"applied_amount"`
+	ExcessAmount  money.Money `gorm:"column:excess_amount;type:decimal(15,2);not null" json:"excess_amount"`
+	// ReplenishedAmount is the amount that restored to the user tenor limit
+	ReplenishedAmount money.Money     `gorm:"column:replenished_amount;type:decimal(15,2);not null" json:"replenished_amount"`
+	Reference         nullable.String `gorm:"column:reference;type:varchar(255)" json:"reference"`
+	RecordedBy        string          `gorm:"column:recorded_by;type:uuid;not null" json:"recorded_by"`
+	PaidAt            time.Time       `gorm:"column:paid_at;type:timestamp;not null" json:"paid_at"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+
+	Allocations []*PaymentAllocation `json:"allocations,omitempty" gorm:"<-:false;foreignKey:payment_id;references:id"`
+}
+
+func (Payment) TableName() string {
+	return "payments"
+}
+
+type PaymentAllocation struct {
+	ID        string `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	PaymentID string `gorm:"column:payment_id;type:uuid;not null" json:"payment_id"`
+	// InstallmentID is null for the early termination fee, that does not belong to any installment
+	InstallmentID   nullable.String `gorm:"column:installment_id;type:uuid" json:"installment_id"`
+	PrincipalAmount money.Money     `gorm:"column:principal_amount;type:decimal(15,2);not null" json:"principal_amount"`
+	InterestAmount  money.Money     `gorm:"column:interest_amount;type:decimal(15,2);not null" json:"interest_amount"`
+	FeeAmount       money.Money     `gorm:"column:fee_amount;type:decimal(15,2);not null" json:"fee_amount"`
+	PenaltyAmount   money.Money     `gorm:"column:penalty_amount;type:decimal(15,2);not null" json:"penalty_amount"`
+	// TerminationFeeAmount is the early termination fee that paid with the settlement
+	TerminationFeeAmount money.Money `gorm:"column:termination_fee_amount;type:decimal(15,2);not null" json:"termination_fee_amount"`
+	Amount               money.Money `gorm:"column:amount;type:decimal(15,2);not null" json:"amount"`
+	CreatedAt            time.Time   `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+}
+
+func (PaymentAllocation) TableName() string {
+	return "payment_allocations"
+}
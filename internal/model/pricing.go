@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"time"
+	"xyz/pkg/money"
+	"xyz/pkg/pricing"
+)
+
+// DefaultProduct is the product code used when transaction doesn't specify the product
+const DefaultProduct = "default"
+
+// PricingVersion is an effective-dated version of product pricing table
+type PricingVersion struct {
+	ID                    string          `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	ProductCode           string          `gorm:"column:product_code;type:varchar(50);not null" json:"product_code"`
+	Version               int             `gorm:"column:version;type:int;not null" json:"version"`
+	EffectiveFrom         time.Time       `gorm:"column:effective_from;type:timestamp;not null" json:"effective_from"`
+	InterestMethod        string          `gorm:"column:interest_method;type:enum('flat', 'effective');not null" json:"interest_method"`
+	AdminFeeType          string          `gorm:"column:admin_fee_type;type:enum('percent', 'fixed');not null" json:"admin_fee_type"`
+	AdminFeeValue         float64         `gorm:"column:admin_fee_value;type:decimal(15,2);not null" json:"admin_fee_value"`
+	AdminFeeBase          string          `gorm:"column:admin_fee_base;type:enum('principal', 'principal_interest');not null" json:"admin_fee_base"`
+	AdminFeeMin           money.Money     `gorm:"column:admin_fee_min;type:decimal(15,2);not null" json:"admin_fee_min"`
+	AdminFeeMax           money.Money     `gorm:"column:admin_fee_max;type:decimal(15,2);not null" json:"admin_fee_max"`
+	MinDownPaymentPercent float64         `gorm:"column:min_down_payment_percent;type:decimal(5,2);not null" json:"min_down_payment_percent"`
+	CreatedBy             nullable.String `gorm:"column:created_by;type:uuid" json:"created_by"`
+	CreatedAt             time.Time       `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	Rates                 []*PricingRate  `gorm:"<-:false;foreignKey:pricing_version_id;references:id" json:"rates,omitempty"`
+}
+
+func (PricingVersion) TableName() string {
+	return "pricing_versions"
+}
+
+// Rule returns pricing rule of the version with the rate of the tenor
+func (p PricingVersion) Rule(rate PricingRate) pricing.Rule {
+	return pricing.Rule{
+		Method:       p.InterestMethod,
+		InterestRate: rate.InterestRate,
+		FeeType:      p.AdminFeeType,
+		FeeValue:     p.AdminFeeValue,
+		FeeBase:      p.AdminFeeBase,
+		FeeMin:       p.AdminFeeMin,
+		FeeMax:       p.AdminFeeMax,
+	}
+}
+
+// PricingRate is annual interest rate of one tenor in a pricing version
+type PricingRate struct {
+	ID               string  `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	PricingVersionID string  `gorm:"column:pricing_version_id;type:uuid;not null" json:"pricing_version_id"`
+	Tenor            int     `gorm:"column:tenor;type:int;not null" json:"tenor"`
+	InterestRate     float64 `gorm:"column:interest_rate;type:decimal(7,4);not null" json:"interest_rate"`
+}
+
+func (PricingRate) TableName() string {
+	return "pricing_rates"
+}
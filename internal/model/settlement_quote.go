@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"time"
+	"xyz/pkg/money"
+)
+
+const (
+	QuoteACTIVE = "active"
+	QuoteUSED   = "used"
+)
+
+// SettlementQuote is the early settlement (pelunasan dipercepat) amount of a transaction
+type SettlementQuote struct {
+	ID                 string          `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	TransactionID      string          `gorm:"column:transaction_id;type:uuid;not null" json:"transaction_id"`
+	UserID             string          `gorm:"column:user_id;type:uuid;not null" json:"user_id"`
+	RemainingPrincipal money.Money     `gorm:"column:remaining_principal;type:decimal(15,2);not null" json:"remaining_principal"`
+	InterestCharged    money.Money     `gorm:"column:interest_charged;type:decimal(15,2);not null" json:"interest_charged"`
+	InterestWaived     money.Money     `gorm:"column:interest_waived;type:decimal(15,2);not null" json:"interest_waived"`
+	FeeAmount          money.Money     `gorm:"column:fee_amount;type:decimal(15,2);not null" json:"fee_amount"`
+	PenaltyAmount      money.Money     `gorm:"column:penalty_amount;type:decimal(15,2);not null" json:"penalty_amount"`
+	TerminationFee     money.Money     `gorm:"column:termination_fee;type:decimal(15,2);not null" json:"termination_fee"`
+	TotalAmount        money.Money     `gorm:"column:total_amount;type:decimal(15,2);not null" json:"total_amount"`
+	Status             string          `gorm:"column:status;type:enum('active', 'used');not null" json:"status"`
+	PaymentID          nullable.String `gorm:"column:payment_id;type:uuid" json:"payment_id"`
+	ExpiresAt          time.Time       `gorm:"column:expires_at;type:timestamp;not null" json:"expires_at"`
+	CreatedAt          time.Time       `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt          time.Time       `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+func (SettlementQuote) TableName() string {
+	return "settlement_quotes"
+}
+
+// IsExpired check if the quote cannot be used anymore
+func (q SettlementQuote) IsExpired(now time.Time) bool {
+	return q.Status != QuoteACTIVE || !now.Before(q.ExpiresAt)
+}
@@ -7,13 +7,22 @@
 
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+	"xyz/pkg/money"
+)
 
 type TenorLimits struct {
-	ID            string  `gorm:";column:id;primaryKey;type:uuid" json:"id"`
-	UserID        string  `gorm:";column:user_id;type:uuid" json:"-"`
-	TenorInMonths int     `gorm:";column:tenor_in_months;type:int" json:"tenor_in_months"`
-	LimitAmount   float64 `gorm:";column:limit_amount;type:int" json:"limit_amount"`
+	ID            string      `gorm:";column:id;primaryKey;type:uuid" json:"id"`
+	UserID        string      `gorm:";column:user_id;type:uuid" json:"-"`
+	TenorInMonths int         `gorm:";column:tenor_in_months;type:int" json:"tenor_in_months"`
+	LimitAmount   money.Money `gorm:";column:limit_amount;type:decimal(15,2)" json:"limit_amount"`
+	HeldAmount    money.Money `gorm:"column:held_amount;->;-:migration" json:"held_amount"`
+	// GrantedAmount is the limit given to the user, it is only changed by admin adjustments
+	GrantedAmount money.Money `gorm:"column:granted_amount;type:decimal(15,2);not null" json:"-"`
+	// Version is incremented on every limit change, used by the optimistic limit strategy
+	Version int64 `gorm:"column:version;type:int;not null" json:"-"`
 
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
@@ -24,3 +33,17 @@ type TenorLimits struct {
 func (t *TenorLimits) TableName() string {
 	return "user_tenor_limits"
 }
+
+// Available is the limit that can be used, the limit amount minus the active limit holds (HeldAmount).
+// HeldAmount is not saved, it is filled by the repository
+func (t TenorLimits) Available() money.Money {
+	return t.LimitAmount.Sub(t.HeldAmount)
+}
+
+func (t TenorLimits) MarshalJSON() ([]byte, error) {
+	type alias TenorLimits
+	return json.Marshal(struct {
+		alias
+		AvailableAmount money.Money `json:"available_amount"`
+	}{alias(t), t.Available()})
+}
@@ -7,30 +7,82 @@
 
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"go.portalnesia.com/nullable"
+	"time"
+	"xyz/pkg/money"
+)
 
 const (
-	TrxPENDING  = "pending"
-	TrxAPPROVED = "approved"
-	TrxREJECTED = "rejected"
+	TrxPENDING   = "pending"
+	TrxAPPROVED  = "approved"
+	TrxREJECTED  = "rejected"
+	TrxSETTLED   = "settled"
+	TrxCANCELLED = "cancelled"
 )
 
+// trxTransitions list of allowed status transitions
+var trxTransitions = map[string][]string{
+	TrxPENDING:  {TrxAPPROVED, TrxREJECTED, TrxCANCELLED},
+	TrxAPPROVED: {TrxSETTLED},
+}
+
 type Transaction struct {
-	ID                string    `gorm:"column:id;type:uuid;primarykey" json:"id"`
-	ContractNumber    string    `gorm:"column:contract_number;type:varchar(255);not null;unique" json:"contract_number"`
-	UserID            string    `gorm:"column:user_id;type:uuid;not null" json:"user_id"`
-	OTR               float64   `gorm:"column:otr;type:decimal(10,2);not null" json:"otr"`
-	AdminFee          float64   `gorm:"column:admin_fee;type:decimal(10,2);not null" json:"admin_fee"`
-	InstallmentAmount float64   `gorm:"column:installment_amount;type:decimal(10,2);not null" json:"installment_amount"`
-	InterestAmount    float64   `gorm:"column:interest_amount;type:decimal(10,2);not null" json:"interest_amount"`
-	AssetName         string    `gorm:"column:asset_name;type:varchar(255);not null" json:"asset_name"`
-	Tenor             int       `gorm:"column:tenor;type:int;not null" json:"tenor"`
-	TransactionDate   time.Time `gorm:"column:transaction_date;type:timestamp;not null" json:"transaction_date"`
-	Status            string    `gorm:"column:status;type:enum('pending', 'approved', 'rejected');not null" json:"status"`
-	CreatedAt         time.Time `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
-	UpdatedAt         time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+	ID                string          `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	ContractNumber    string          `gorm:"column:contract_number;type:varchar(255);not null;unique" json:"contract_number"`
+	UserID            string          `gorm:"column:user_id;type:uuid;not null" json:"user_id"`
+	OTR               money.Money     `gorm:"column:otr;type:decimal(15,2);not null" json:"otr"`
+	DownPayment       money.Money     `gorm:"column:down_payment;type:decimal(15,2);not null" json:"down_payment"`
+	AdminFee          money.Money     `gorm:"column:admin_fee;type:decimal(15,2);not null" json:"admin_fee"`
+	InstallmentAmount money.Money     `gorm:"column:installment_amount;type:decimal(15,2);not null" json:"installment_amount"`
+	InterestAmount    money.Money     `gorm:"column:interest_amount;type:decimal(15,2);not null" json:"interest_amount"`
+	PenaltyAmount     money.Money     `gorm:"column:penalty_amount;type:decimal(15,2);not null" json:"penalty_amount"`
+	AssetName         string          `gorm:"column:asset_name;type:varchar(255);not null" json:"asset_name"`
+	AssetCategory     nullable.String `gorm:"column:asset_category;type:varchar(50)" json:"asset_category"`
+	ProductCode       string          `gorm:"column:product_code;type:varchar(50);not null" json:"product_code"`
+	PricingVersionID  nullable.String `gorm:"column:pricing_version_id;type:uuid" json:"pricing_version_id"`
+	MerchantID        nullable.String `gorm:"column:merchant_id;type:uuid" json:"merchant_id"`
+	SalesChannel      nullable.String `gorm:"column:sales_channel;type:enum('offline', 'online', 'app')" json:"sales_channel"`
+	Tenor             int             `gorm:"column:tenor;type:int;not null" json:"tenor"`
+	TransactionDate   time.Time       `gorm:"column:transaction_date;type:timestamp;not null" json:"transaction_date"`
+	Status            string          `gorm:"column:status;type:enum('pending', 'approved', 'rejected', 'settled', 'cancelled');not null" json:"status"`
+	RejectionReason   nullable.String `gorm:"column:rejection_reason;type:varchar(255)" json:"rejection_reason"`
+	ReviewedBy        nullable.String `gorm:"column:reviewed_by;type:uuid" json:"reviewed_by"`
+	ReviewedAt        nullable.Time   `gorm:"column:reviewed_at;type:timestamp" json:"reviewed_at"`
+	CancelledAt       nullable.Time   `gorm:"column:cancelled_at;type:timestamp" json:"cancelled_at"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
 }
 
 func (Transaction) TableName() string {
 	return "transactions"
 }
+
+// FinancedAmount is the principal that is financed, OTR minus down payment
+func (t Transaction) FinancedAmount() money.Money {
+	return t.OTR.Sub(t.DownPayment)
+}
+
+// TotalAmount is the amount that deducted from user tenor limit
+func (t Transaction) TotalAmount() money.Money {
+	return money.Sum(t.FinancedAmount(), t.InterestAmount, t.AdminFee)
+}
+
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type alias Transaction
+	return json.Marshal(struct {
+		alias
+		FinancedAmount money.Money `json:"financed_amount"`
+	}{alias(t), t.FinancedAmount()})
+}
+
+// CanTransitionTo check if transaction status can be changed to the given status
+func (t Transaction) CanTransitionTo(status string) bool {
+	for _, s := range trxTransitions[t.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
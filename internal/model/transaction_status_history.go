@@ -0,0 +1,31 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package model
+
+import (
+	"go.portalnesia.com/nullable"
+	"time"
+)
+
+// TransactionStatusHistory is audit trail of transaction status changes.
+//
+// FromStatus is empty when the transaction is created
+type TransactionStatusHistory struct {
+	ID            string          `gorm:"column:id;type:uuid;primarykey" json:"id"`
+	TransactionID string          `gorm:"column:transaction_id;type:uuid;not null" json:"transaction_id"`
+	FromStatus    nullable.String `gorm:"column:from_status;type:enum('pending', 'approved', 'rejected', 'settled', 'cancelled')" json:"from_status"`
+	ToStatus      string          `gorm:"column:to_status;type:enum('pending', 'approved', 'rejected', 'settled', 'cancelled');not null" json:"to_status"`
+	ActorID       string          `gorm:"column:actor_id;type:uuid;not null" json:"actor_id"`
+	Reason        nullable.String `gorm:"column:reason;type:varchar(255)" json:"reason"`
+	ChangedAt     time.Time       `gorm:"column:changed_at;type:timestamp;not null" json:"changed_at"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"column:created_at;type:timestamp;autoCreateTime"`
+}
+
+func (TransactionStatusHistory) TableName() string {
+	return "transaction_status_history"
+}
@@ -14,6 +14,11 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUSER  = "user"
+	RoleADMIN = "admin"
+)
+
 type User struct {
 	ID             string          `gorm:";column:id;primaryKey;type:uuid" json:"id"`
 	NIK            string          `json:"nik" gorm:";column:nik;unique;type:varchar(16)"`
@@ -22,6 +27,7 @@ type User struct {
 	BirthPlace     string          `json:"birth_place"  gorm:"column:birth_place;type:varchar(255)"`
 	BirthDate      string          `json:"birth_date" gorm:"column:birth_date;type:date"`
 	Salary         float64         `json:"salary" gorm:"column:salary;type:decimal"`
+	Role           string          `json:"role" gorm:"column:role;type:enum('user', 'admin');default:user"`
 	KTPPhotoURL    nullable.String `json:"ktp_photo_url" gorm:"column:ktp_photo_url;type:varchar(255)"`
 	SelfiePhotoURL nullable.String `json:"selfie_photo_url" gorm:"column:selfie_photo_url;type:varchar(255)"`
 	Date
@@ -41,6 +47,10 @@ func (u *User) AfterDelete(tx *gorm.DB) error {
 	}).Error
 }
 
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleADMIN
+}
+
 func (u *User) HashPassword(passwordString string) {
 	saltPassword := passwordString + viper.GetString("secret.password_salt")
 	hashPassword := pncrypto.HashPassword(saltPassword)
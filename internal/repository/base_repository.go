@@ -15,6 +15,9 @@ import (
 type RepoRegistry struct {
 	UserRepository        UserRepository
 	TransactionRepository TransactionRepository
+	PaymentRepository     PaymentRepository
+	PricingRepository     PricingRepository
+	MerchantRepository    MerchantRepository
 }
 
 type BaseRepository interface {
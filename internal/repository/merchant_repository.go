@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+	"xyz/internal/model"
+)
+
+type MerchantRepository interface {
+	BaseRepository
+
+	Create(ctx context.Context, merchant *model.Merchant, opts ...Option) error
+	GetByID(ctx context.Context, id string, opts ...Option) (*model.Merchant, error)
+	Save(ctx context.Context, merchant *model.Merchant, opts ...Option) error
+	List(ctx context.Context, opts ...Option) (total int64, merchants []*model.Merchant, err error)
+	// Report returns transaction volume and approval rate per merchant of transactions between from and to, both inclusive by date.
+	// Zero from or to is ignored. Merchants without transactions are included with zero values
+	Report(ctx context.Context, from, to time.Time, opts ...Option) ([]*model.MerchantReport, error)
+}
+type merchantRepositoryImpl struct {
+	base
+}
+
+func NewMerchantRepository(db *gorm.DB) MerchantRepository {
+	return &merchantRepositoryImpl{
+		base: base{
+			db: db,
+		},
+	}
+}
+
+func (r merchantRepositoryImpl) Create(ctx context.Context, merchant *model.Merchant, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(merchant).Error
+}
+
+func (r merchantRepositoryImpl) GetByID(ctx context.Context, id string, opts ...Option) (*model.Merchant, error) {
+	var merchant model.Merchant
+	if err := r.getDatabase(ctx, opts...).Where("id = ?", id).First(&merchant).Error; err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+func (r merchantRepositoryImpl) Save(ctx context.Context, merchant *model.Merchant, opts ...Option) error {
+	merchant.UpdatedAt = time.Now()
+	return r.getDatabase(ctx, opts...).Save(merchant).Error
+}
+
+func (r merchantRepositoryImpl) List(ctx context.Context, opts ...Option) (total int64, merchants []*model.Merchant, err error) {
+	db := r.getDatabase(ctx, opts...).Model(&model.Merchant{}).Session(&gorm.Session{})
+
+	// count all filtered rows, without pagination
+	err = db.Offset(-1).Limit(-1).Count(&total).Error
+	if err != nil {
+		return
+	}
+
+	err = db.Find(&merchants).Error
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (r merchantRepositoryImpl) Report(ctx context.Context, from, to time.Time, opts ...Option) ([]*model.MerchantReport, error) {
+	// date filter is on the join, so merchants without transactions in the range are still listed
+	join := "LEFT JOIN transactions ON transactions.merchant_id = merchants.id"
+	var args []any
+	if !from.IsZero() {
+		join += " AND transactions.transaction_date >= ?"
+		args = append(args, from)
+	}
+	if !to.IsZero() {
+		join += " AND transactions.transaction_date < ?"
+		args = append(args, to.AddDate(0, 0, 1))
+	}
+
+	var reports []*model.MerchantReport
+	err := r.getDatabase(ctx, opts...).Model(&model.Merchant{}).
+		Select(
+			"merchants.id AS merchant_id, merchants.code, merchants.name, "+
+				"COUNT(transactions.id) AS transaction_count, "+
+				"COALESCE(SUM(transactions.status IN ?), 0) AS approved_count, "+
+				"COALESCE(SUM(transactions.status = ?), 0) AS rejected_count, "+
+				"COALESCE(SUM(CASE WHEN transactions.status IN ? THEN transactions.otr END), 0) AS volume",
+			[]string{model.TrxAPPROVED, model.TrxSETTLED}, model.TrxREJECTED, []string{model.TrxAPPROVED, model.TrxSETTLED},
+		).
+		Joins(join, args...).
+		Group("merchants.id, merchants.code, merchants.name").
+		Order("volume desc, merchants.code asc").
+		Scan(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, report := range reports {
+		report.CalculateApprovalRate()
+	}
+	return reports, nil
+}
@@ -10,6 +10,8 @@ package repository
 import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"strings"
+	"time"
 	"xyz/internal/dto"
 )
 
@@ -40,3 +42,86 @@ func WithPagination(req *dto.Pagination) Option {
 		return db.Offset(offset).Limit(req.Limit)
 	}
 }
+
+// WithStatus filters rows by status column. Empty status is ignored
+func WithStatus(status string) Option {
+	return func(db *gorm.DB) *gorm.DB {
+		if status == "" {
+			return db
+		}
+		return db.Where("status = ?", status)
+	}
+}
+
+// WithTenor filters rows by tenor column. Zero tenor is ignored
+func WithTenor(tenor int) Option {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenor <= 0 {
+			return db
+		}
+		return db.Where("tenor = ?", tenor)
+	}
+}
+
+// WithActive filters only active rows
+func WithActive() Option {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("active = ?", true)
+	}
+}
+
+// WithType filters rows by type column. Empty type is ignored
+func WithType(typ string) Option {
+	return func(db *gorm.DB) *gorm.DB {
+		if typ == "" {
+			return db
+		}
+		return db.Where("type = ?", typ)
+	}
+}
+
+// WithSearch filters rows where column contains the keyword. Empty keyword is ignored.
+//
+// column must not come from user input
+func WithSearch(column, keyword string) Option {
+	return func(db *gorm.DB) *gorm.DB {
+		if keyword == "" {
+			return db
+		}
+		escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(keyword)
+		return db.Where(column+" LIKE ?", "%"+escaped+"%")
+	}
+}
+
+// WithDateRange filters rows where column is between from and to, both inclusive by date.
+// Zero from or to is ignored.
+//
+// column must not come from user input
+func WithDateRange(column string, from, to time.Time) Option {
+	return func(db *gorm.DB) *gorm.DB {
+		if !from.IsZero() {
+			db = db.Where(column+" >= ?", from)
+		}
+		if !to.IsZero() {
+			db = db.Where(column+" < ?", to.AddDate(0, 0, 1))
+		}
+		return db
+	}
+}
+
+// WithSort orders rows by the sort field. Only fields listed in allowed (field name to column) are used,
+// otherwise the first column of defaults is used.
+//
+// id is always added as the last order, so the rows order is stable across pages
+func WithSort(field, direction string, allowed map[string]string, defaults string) Option {
+	return func(db *gorm.DB) *gorm.DB {
+		column, ok := allowed[field]
+		if !ok {
+			column = defaults
+		}
+
+		desc := !strings.EqualFold(direction, "asc")
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc}).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+	}
+}
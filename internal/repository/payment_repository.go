@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+	"xyz/internal/model"
+	"xyz/pkg/money"
+)
+
+type PaymentRepository interface {
+	BaseRepository
+
+	Create(ctx context.Context, payment *model.Payment, opts ...Option) error
+	CreateAllocations(ctx context.Context, allocations []*model.PaymentAllocation, opts ...Option) error
+	// SumReplenished returns the total amount that the payments of the transaction restored to the tenor limit
+	SumReplenished(ctx context.Context, transactionId string, opts ...Option) (money.Money, error)
+	CreateQuote(ctx context.Context, quote *model.SettlementQuote, opts ...Option) error
+	GetQuote(ctx context.Context, id string, opts ...Option) (*model.SettlementQuote, error)
+	SaveQuote(ctx context.Context, quote *model.SettlementQuote, opts ...Option) error
+}
+type paymentRepositoryImpl struct {
+	base
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepositoryImpl{
+		base: base{
+			db: db,
+		},
+	}
+}
+
+func (r paymentRepositoryImpl) Create(ctx context.Context, payment *model.Payment, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(payment).Error
+}
+
+func (r paymentRepositoryImpl) CreateAllocations(ctx context.Context, allocations []*model.PaymentAllocation, opts ...Option) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	return r.getDatabase(ctx, opts...).Create(allocations).Error
+}
+
+func (r paymentRepositoryImpl) SumReplenished(ctx context.Context, transactionId string, opts ...Option) (money.Money, error) {
+	var total money.Money
+	err := r.getDatabase(ctx, opts...).Model(&model.Payment{}).
+		Select("COALESCE(SUM(replenished_amount), 0)").
+		Where("transaction_id = ?", transactionId).
+		Row().Scan(&total)
+	return total, err
+}
+
+func (r paymentRepositoryImpl) CreateQuote(ctx context.Context, quote *model.SettlementQuote, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(quote).Error
+}
+
+func (r paymentRepositoryImpl) GetQuote(ctx context.Context, id string, opts ...Option) (*model.SettlementQuote, error) {
+	var quote model.SettlementQuote
+	if err := r.getDatabase(ctx, opts...).Where("id = ?", id).First(&quote).Error; err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r paymentRepositoryImpl) SaveQuote(ctx context.Context, quote *model.SettlementQuote, opts ...Option) error {
+	quote.UpdatedAt = time.Now()
+	return r.getDatabase(ctx, opts...).Save(quote).Error
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+	"xyz/internal/model"
+)
+
+type PricingRepository interface {
+	BaseRepository
+
+	// GetActiveVersion get the latest version of the product that is effective at date
+	GetActiveVersion(ctx context.Context, productCode string, date time.Time, opts ...Option) (*model.PricingVersion, error)
+	GetLatestVersion(ctx context.Context, productCode string, opts ...Option) (*model.PricingVersion, error)
+	GetRate(ctx context.Context, versionId string, tenor int, opts ...Option) (*model.PricingRate, error)
+	// ListActiveTenors returns the tenors that have a rate in the active version of the product at date, ordered ascending
+	ListActiveTenors(ctx context.Context, productCode string, date time.Time, opts ...Option) ([]int, error)
+	ListVersions(ctx context.Context, productCode string, opts ...Option) ([]*model.PricingVersion, error)
+	CreateVersion(ctx context.Context, version *model.PricingVersion, opts ...Option) error
+	CreateRates(ctx context.Context, rates []*model.PricingRate, opts ...Option) error
+	GetCategory(ctx context.Context, code string, opts ...Option) (*model.AssetCategory, error)
+	ListCategories(ctx context.Context, opts ...Option) ([]*model.AssetCategory, error)
+	CreateCategory(ctx context.Context, category *model.AssetCategory, opts ...Option) error
+	SaveCategory(ctx context.Context, category *model.AssetCategory, opts ...Option) error
+}
+type pricingRepositoryImpl struct {
+	base
+}
+
+func NewPricingRepository(db *gorm.DB) PricingRepository {
+	return &pricingRepositoryImpl{
+		base: base{
+			db: db,
+		},
+	}
+}
+
+func (r pricingRepositoryImpl) GetActiveVersion(ctx context.Context, productCode string, date time.Time, opts ...Option) (*model.PricingVersion, error) {
+	var version model.PricingVersion
+	if err := r.getDatabase(ctx, opts...).
+		Where("product_code = ? AND effective_from <= ?", productCode, date).
+		Order("effective_from desc, version desc").
+		First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r pricingRepositoryImpl) GetLatestVersion(ctx context.Context, productCode string, opts ...Option) (*model.PricingVersion, error) {
+	var version model.PricingVersion
+	if err := r.getDatabase(ctx, opts...).Where("product_code = ?", productCode).Order("version desc").First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r pricingRepositoryImpl) GetRate(ctx context.Context, versionId string, tenor int, opts ...Option) (*model.PricingRate, error) {
+	var rate model.PricingRate
+	if err := r.getDatabase(ctx, opts...).Where("pricing_version_id = ? AND tenor = ?", versionId, tenor).First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r pricingRepositoryImpl) ListActiveTenors(ctx context.Context, productCode string, date time.Time, opts ...Option) ([]int, error) {
+	var tenors []int
+	active := r.getDatabase(ctx).Model(&model.PricingVersion{}).
+		Select("id").
+		Where("product_code = ? AND effective_from <= ?", productCode, date).
+		Order("effective_from desc, version desc").
+		Limit(1)
+	if err := r.getDatabase(ctx, opts...).Model(&model.PricingRate{}).
+		Where("pricing_version_id = (?)", active).
+		Order("tenor asc").
+		Pluck("tenor", &tenors).Error; err != nil {
+		return nil, err
+	}
+	return tenors, nil
+}
+
+func (r pricingRepositoryImpl) ListVersions(ctx context.Context, productCode string, opts ...Option) ([]*model.PricingVersion, error) {
+	var versions []*model.PricingVersion
+	if err := r.getDatabase(ctx, opts...).
+		Where("product_code = ?", productCode).
+		Preload("Rates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("tenor asc")
+		}).
+		Order("version desc").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r pricingRepositoryImpl) CreateVersion(ctx context.Context, version *model.PricingVersion, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(version).Error
+}
+
+func (r pricingRepositoryImpl) CreateRates(ctx context.Context, rates []*model.PricingRate, opts ...Option) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.getDatabase(ctx, opts...).Create(rates).Error
+}
+
+func (r pricingRepositoryImpl) GetCategory(ctx context.Context, code string, opts ...Option) (*model.AssetCategory, error) {
+	var category model.AssetCategory
+	if err := r.getDatabase(ctx, opts...).Where("code = ?", code).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r pricingRepositoryImpl) ListCategories(ctx context.Context, opts ...Option) ([]*model.AssetCategory, error) {
+	var categories []*model.AssetCategory
+	if err := r.getDatabase(ctx, opts...).Order("code asc").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r pricingRepositoryImpl) CreateCategory(ctx context.Context, category *model.AssetCategory, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(category).Error
+}
+
+func (r pricingRepositoryImpl) SaveCategory(ctx context.Context, category *model.AssetCategory, opts ...Option) error {
+	category.UpdatedAt = time.Now()
+	return r.getDatabase(ctx, opts...).Save(category).Error
+}
@@ -10,15 +10,57 @@ package repository
 import (
 	"context"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 	"xyz/internal/model"
+	"xyz/pkg/money"
 )
 
 type TransactionRepository interface {
 	BaseRepository
 
 	Create(ctx context.Context, user *model.Transaction, opts ...Option) error
+	GetByID(ctx context.Context, id string, opts ...Option) (*model.Transaction, error)
+	GetByContractNumber(ctx context.Context, contractNumber string, opts ...Option) (*model.Transaction, error)
+	Save(ctx context.Context, transaction *model.Transaction, opts ...Option) error
 	GetLimit(ctx context.Context, userId string, tenor int, opts ...Option) (*model.TenorLimits, error)
+	// UpdateTenorLimit saves the tenor limit and increments its version
 	UpdateTenorLimit(ctx context.Context, tenorLimit *model.TenorLimits, opts ...Option) error
+	// DebitTenorLimit deducts amount from the tenor limit only if its version is not changed since it was read
+	// and the available limit still covers the amount. It returns false if no row is updated
+	DebitTenorLimit(ctx context.Context, tenorLimit *model.TenorLimits, amount money.Money, opts ...Option) (bool, error)
+	GetCreditLine(ctx context.Context, userId string, opts ...Option) (*model.CreditLine, error)
+	// UpdateCreditLine saves the credit line and increments its version
+	UpdateCreditLine(ctx context.Context, creditLine *model.CreditLine, opts ...Option) error
+	// DebitCreditLine deducts amount from the credit line only if its version is not changed since it was read
+	// and the available credit line still covers the amount. It returns false if no row is updated
+	DebitCreditLine(ctx context.Context, creditLine *model.CreditLine, amount money.Money, opts ...Option) (bool, error)
+	CreateInstallments(ctx context.Context, installments []*model.Installment, opts ...Option) error
+	ListInstallments(ctx context.Context, transactionId string, opts ...Option) ([]*model.Installment, error)
+	SaveInstallments(ctx context.Context, installments []*model.Installment, opts ...Option) error
+	GetInstallment(ctx context.Context, id string, opts ...Option) (*model.Installment, error)
+	ListOverdueInstallments(ctx context.Context, date time.Time, opts ...Option) ([]*model.Installment, error)
+	// CreatePenalty saves the penalty ledger. It returns false if penalty of the installment is already accrued on the same date
+	CreatePenalty(ctx context.Context, penalty *model.InstallmentPenalty, opts ...Option) (bool, error)
+	AddTransactionPenalty(ctx context.Context, id string, amount money.Money, opts ...Option) error
+	// NextContractSequence increments and returns the running number of the key.
+	// The sequence row stays locked until the database transaction ends, so the number is gap-free when the transaction is rolled back
+	NextContractSequence(ctx context.Context, key string, opts ...Option) (int64, error)
+	CreateHold(ctx context.Context, hold *model.LimitHold, opts ...Option) error
+	GetHold(ctx context.Context, id string, opts ...Option) (*model.LimitHold, error)
+	SaveHold(ctx context.Context, hold *model.LimitHold, opts ...Option) error
+	// ExpireHolds marks active holds that passed their expiry time as expired, it returns the number of expired holds
+	ExpireHolds(ctx context.Context, now time.Time, opts ...Option) (int64, error)
+	// CreateLedger appends the entry to the limit ledger, entries are never updated
+	CreateLedger(ctx context.Context, entry *model.LimitLedger, opts ...Option) error
+	ListLedger(ctx context.Context, userId string, opts ...Option) (total int64, entries []*model.LimitLedger, err error)
+	// ReconcileLimits returns the reconciliation of the credit lines and tenor limits of the next `size` users with a credit line after afterUserId,
+	// ordered by user ID and tenor, the credit line first
+	ReconcileLimits(ctx context.Context, afterUserId string, size int, opts ...Option) ([]*model.LimitReconciliation, error)
+	// ListUserLimitReconciliations returns the reconciliation of the credit line and tenor limits of one user, the credit line first
+	ListUserLimitReconciliations(ctx context.Context, userId string, opts ...Option) ([]*model.LimitReconciliation, error)
+	CreateStatusHistory(ctx context.Context, history *model.TransactionStatusHistory, opts ...Option) error
+	ListStatusHistory(ctx context.Context, transactionId string, opts ...Option) ([]*model.TransactionStatusHistory, error)
 }
 type transactionRepositoryImpl struct {
 	base
@@ -36,14 +78,310 @@ func (r transactionRepositoryImpl) Create(ctx context.Context, transaction *mode
 	return r.getDatabase(ctx, opts...).Create(transaction).Error
 }
 
+func (r transactionRepositoryImpl) GetByID(ctx context.Context, id string, opts ...Option) (*model.Transaction, error) {
+	var transaction model.Transaction
+	if err := r.getDatabase(ctx, opts...).Where("id = ?", id).First(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (r transactionRepositoryImpl) GetByContractNumber(ctx context.Context, contractNumber string, opts ...Option) (*model.Transaction, error) {
+	var transaction model.Transaction
+	if err := r.getDatabase(ctx, opts...).Where("contract_number = ?", contractNumber).First(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (r transactionRepositoryImpl) Save(ctx context.Context, transaction *model.Transaction, opts ...Option) error {
+	transaction.UpdatedAt = time.Now()
+	return r.getDatabase(ctx, opts...).Save(transaction).Error
+}
+
 func (r transactionRepositoryImpl) GetLimit(ctx context.Context, userId string, tenor int, opts ...Option) (*model.TenorLimits, error) {
 	var tenorLimit model.TenorLimits
-	if err := r.getDatabase(ctx, opts...).Where("user_id = ? AND tenor_in_months = ?", userId, tenor).First(&tenorLimit).Error; err != nil {
+	if err := r.getDatabase(ctx, opts...).Scopes(withHeldAmount).Where("user_id = ? AND tenor_in_months = ?", userId, tenor).First(&tenorLimit).Error; err != nil {
 		return nil, err
 	}
 	return &tenorLimit, nil
 }
 
 func (r transactionRepositoryImpl) UpdateTenorLimit(ctx context.Context, tenorLimit *model.TenorLimits, opts ...Option) error {
+	tenorLimit.Version++
 	return r.getDatabase(ctx, opts...).Save(tenorLimit).Error
 }
+
+func (r transactionRepositoryImpl) DebitTenorLimit(ctx context.Context, tenorLimit *model.TenorLimits, amount money.Money, opts ...Option) (bool, error) {
+	now := time.Now()
+	result := r.getDatabase(ctx, opts...).Model(&model.TenorLimits{}).
+		Where("id = ? AND version = ? AND limit_amount >= ?", tenorLimit.ID, tenorLimit.Version, amount.Add(tenorLimit.HeldAmount)).
+		Updates(map[string]any{
+			"limit_amount": gorm.Expr("limit_amount - ?", amount),
+			"version":      gorm.Expr("version + 1"),
+			"updated_at":   now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	tenorLimit.LimitAmount = tenorLimit.LimitAmount.Sub(amount)
+	tenorLimit.Version++
+	tenorLimit.UpdatedAt = now
+	return true, nil
+}
+
+func (r transactionRepositoryImpl) GetCreditLine(ctx context.Context, userId string, opts ...Option) (*model.CreditLine, error) {
+	var creditLine model.CreditLine
+	if err := r.getDatabase(ctx, opts...).Scopes(withCreditLineHeldAmount).Where("user_id = ?", userId).First(&creditLine).Error; err != nil {
+		return nil, err
+	}
+	return &creditLine, nil
+}
+
+func (r transactionRepositoryImpl) UpdateCreditLine(ctx context.Context, creditLine *model.CreditLine, opts ...Option) error {
+	creditLine.Version++
+	return r.getDatabase(ctx, opts...).Save(creditLine).Error
+}
+
+func (r transactionRepositoryImpl) DebitCreditLine(ctx context.Context, creditLine *model.CreditLine, amount money.Money, opts ...Option) (bool, error) {
+	now := time.Now()
+	result := r.getDatabase(ctx, opts...).Model(&model.CreditLine{}).
+		Where("id = ? AND version = ? AND limit_amount >= ?", creditLine.ID, creditLine.Version, amount.Add(creditLine.HeldAmount)).
+		Updates(map[string]any{
+			"limit_amount": gorm.Expr("limit_amount - ?", amount),
+			"version":      gorm.Expr("version + 1"),
+			"updated_at":   now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	creditLine.LimitAmount = creditLine.LimitAmount.Sub(amount)
+	creditLine.Version++
+	creditLine.UpdatedAt = now
+	return true, nil
+}
+
+func (r transactionRepositoryImpl) CreateInstallments(ctx context.Context, installments []*model.Installment, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(installments).Error
+}
+
+func (r transactionRepositoryImpl) ListInstallments(ctx context.Context, transactionId string, opts ...Option) ([]*model.Installment, error) {
+	var installments []*model.Installment
+	if err := r.getDatabase(ctx, opts...).Where("transaction_id = ?", transactionId).Order("sequence asc").Find(&installments).Error; err != nil {
+		return nil, err
+	}
+	return installments, nil
+}
+
+func (r transactionRepositoryImpl) SaveInstallments(ctx context.Context, installments []*model.Installment, opts ...Option) error {
+	if len(installments) == 0 {
+		return nil
+	}
+	return r.getDatabase(ctx, opts...).Save(installments).Error
+}
+
+func (r transactionRepositoryImpl) GetInstallment(ctx context.Context, id string, opts ...Option) (*model.Installment, error) {
+	var installment model.Installment
+	if err := r.getDatabase(ctx, opts...).Where("id = ?", id).First(&installment).Error; err != nil {
+		return nil, err
+	}
+	return &installment, nil
+}
+
+func (r transactionRepositoryImpl) ListOverdueInstallments(ctx context.Context, date time.Time, opts ...Option) ([]*model.Installment, error) {
+	var installments []*model.Installment
+	err := r.getDatabase(ctx, opts...).
+		Select("installments.*").
+		Joins("JOIN transactions ON transactions.id = installments.transaction_id").
+		Where("installments.status IN ? AND installments.due_date < ? AND transactions.status = ?", []string{model.InstallmentUNPAID, model.InstallmentPARTIAL}, date.Format(time.DateOnly), model.TrxAPPROVED).
+		Order("installments.due_date asc").
+		Find(&installments).Error
+	if err != nil {
+		return nil, err
+	}
+	return installments, nil
+}
+
+func (r transactionRepositoryImpl) CreatePenalty(ctx context.Context, penalty *model.InstallmentPenalty, opts ...Option) (bool, error) {
+	result := r.getDatabase(ctx, opts...).Clauses(clause.OnConflict{DoNothing: true}).Create(penalty)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r transactionRepositoryImpl) AddTransactionPenalty(ctx context.Context, id string, amount money.Money, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Model(&model.Transaction{}).Where("id = ?", id).UpdateColumn("penalty_amount", gorm.Expr("penalty_amount + ?", amount)).Error
+}
+
+func (r transactionRepositoryImpl) NextContractSequence(ctx context.Context, key string, opts ...Option) (int64, error) {
+	sequence := model.ContractSequence{SequenceKey: key, LastNumber: 1}
+	err := r.getDatabase(ctx, opts...).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"last_number": gorm.Expr("last_number + 1"),
+			"updated_at":  time.Now(),
+		}),
+	}).Create(&sequence).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if err = r.getDatabase(ctx, opts...).Where("sequence_key = ?", key).First(&sequence).Error; err != nil {
+		return 0, err
+	}
+	return sequence.LastNumber, nil
+}
+
+func (r transactionRepositoryImpl) CreateHold(ctx context.Context, hold *model.LimitHold, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(hold).Error
+}
+
+func (r transactionRepositoryImpl) GetHold(ctx context.Context, id string, opts ...Option) (*model.LimitHold, error) {
+	var hold model.LimitHold
+	if err := r.getDatabase(ctx, opts...).Where("id = ?", id).First(&hold).Error; err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r transactionRepositoryImpl) SaveHold(ctx context.Context, hold *model.LimitHold, opts ...Option) error {
+	hold.UpdatedAt = time.Now()
+	return r.getDatabase(ctx, opts...).Save(hold).Error
+}
+
+func (r transactionRepositoryImpl) ExpireHolds(ctx context.Context, now time.Time, opts ...Option) (int64, error) {
+	result := r.getDatabase(ctx, opts...).Model(&model.LimitHold{}).
+		Where("status = ? AND expires_at <= ?", model.HoldACTIVE, now).
+		Updates(map[string]any{"status": model.HoldEXPIRED, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+func (r transactionRepositoryImpl) CreateLedger(ctx context.Context, entry *model.LimitLedger, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(entry).Error
+}
+
+func (r transactionRepositoryImpl) ListLedger(ctx context.Context, userId string, opts ...Option) (total int64, entries []*model.LimitLedger, err error) {
+	db := r.getDatabase(ctx, opts...).Model(&model.LimitLedger{}).Where("user_id = ?", userId).Session(&gorm.Session{})
+
+	// count all filtered rows, without pagination
+	err = db.Offset(-1).Limit(-1).Count(&total).Error
+	if err != nil {
+		return
+	}
+
+	err = db.Find(&entries).Error
+	return
+}
+
+func (r transactionRepositoryImpl) ReconcileLimits(ctx context.Context, afterUserId string, size int, opts ...Option) ([]*model.LimitReconciliation, error) {
+	// users are paged over their credit lines with keyset on the user ID, so a user with a credit line and no tenor limit is scanned too,
+	// and the batches do not shift when limits change during the scan. The tenor limits of the page are added by reconcileLimits
+	var userIds []string
+	err := r.getDatabase(ctx).Model(&model.CreditLine{}).
+		Where("user_id > ?", afterUserId).
+		Order("user_id asc").
+		Limit(size).
+		Pluck("user_id", &userIds).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+
+	return r.reconcileLimits(ctx, userIds, opts...)
+}
+
+func (r transactionRepositoryImpl) ListUserLimitReconciliations(ctx context.Context, userId string, opts ...Option) ([]*model.LimitReconciliation, error) {
+	return r.reconcileLimits(ctx, []string{userId}, opts...)
+}
+
+func (r transactionRepositoryImpl) reconcileLimits(ctx context.Context, userIds []string, opts ...Option) ([]*model.LimitReconciliation, error) {
+	creditLines := withCreditLineReconciliation(r.getDatabase(ctx)).Where("user_credit_lines.user_id IN ?", userIds)
+	tenorLimits := withTenorLimitReconciliation(r.getDatabase(ctx)).Where("user_tenor_limits.user_id IN ?", userIds)
+
+	// the credit line has tenor 0, so it is ordered before the tenor limits of the user
+	var reconciliations []*model.LimitReconciliation
+	err := r.getDatabase(ctx, opts...).
+		Raw("(?) UNION ALL (?) ORDER BY user_id asc, tenor asc", creditLines, tenorLimits).
+		Scan(&reconciliations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reconciliation := range reconciliations {
+		reconciliation.Calculate()
+	}
+	return reconciliations, nil
+}
+
+func (r transactionRepositoryImpl) CreateStatusHistory(ctx context.Context, history *model.TransactionStatusHistory, opts ...Option) error {
+	return r.getDatabase(ctx, opts...).Create(history).Error
+}
+
+func (r transactionRepositoryImpl) ListStatusHistory(ctx context.Context, transactionId string, opts ...Option) ([]*model.TransactionStatusHistory, error) {
+	var histories []*model.TransactionStatusHistory
+	if err := r.getDatabase(ctx, opts...).Where("transaction_id = ?", transactionId).Order("changed_at asc, created_at asc").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+// unpaidTransactionAmount is the amount of one transaction that is still deducted from the limit,
+// the total amount minus the amount that its payments gave back
+const unpaidTransactionAmount = "transactions.otr - transactions.down_payment + transactions.interest_amount + transactions.admin_fee - " +
+	"(SELECT COALESCE(SUM(payments.replenished_amount), 0) FROM payments WHERE payments.transaction_id = transactions.id)"
+
+// withTenorLimitReconciliation selects tenor limits with the total amount of their active limit holds as held_amount,
+// and the unpaid amount of their transactions that are not rejected or cancelled as unpaid_amount.
+// The amounts are read from the transactions, payments and limit holds, not from the limit ledger
+func withTenorLimitReconciliation(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.TenorLimits{}).Select(
+		"? AS limit_type, user_tenor_limits.id AS limit_id, user_tenor_limits.user_id, user_tenor_limits.tenor_in_months AS tenor, "+
+			"user_tenor_limits.limit_amount, user_tenor_limits.granted_amount, "+
+			"(SELECT COALESCE(SUM(limit_holds.amount), 0) FROM limit_holds "+
+			"WHERE limit_holds.user_id = user_tenor_limits.user_id AND limit_holds.tenor = user_tenor_limits.tenor_in_months "+
+			"AND limit_holds.status = ? AND limit_holds.expires_at > ?) AS held_amount, "+
+			"(SELECT COALESCE(SUM("+unpaidTransactionAmount+"), 0) FROM transactions "+
+			"WHERE transactions.user_id = user_tenor_limits.user_id AND transactions.tenor = user_tenor_limits.tenor_in_months "+
+			"AND transactions.status NOT IN ?) AS unpaid_amount",
+		model.ReconcileTENORLIMIT, model.HoldACTIVE, time.Now(), []string{model.TrxREJECTED, model.TrxCANCELLED},
+	)
+}
+
+// withCreditLineReconciliation selects credit lines like withTenorLimitReconciliation, with the limit holds and transactions of all tenors
+func withCreditLineReconciliation(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.CreditLine{}).Select(
+		"? AS limit_type, user_credit_lines.id AS limit_id, user_credit_lines.user_id, 0 AS tenor, "+
+			"user_credit_lines.limit_amount, user_credit_lines.granted_amount, "+
+			"(SELECT COALESCE(SUM(limit_holds.amount), 0) FROM limit_holds "+
+			"WHERE limit_holds.user_id = user_credit_lines.user_id "+
+			"AND limit_holds.status = ? AND limit_holds.expires_at > ?) AS held_amount, "+
+			"(SELECT COALESCE(SUM("+unpaidTransactionAmount+"), 0) FROM transactions "+
+			"WHERE transactions.user_id = user_credit_lines.user_id "+
+			"AND transactions.status NOT IN ?) AS unpaid_amount",
+		model.ReconcileCREDITLINE, model.HoldACTIVE, time.Now(), []string{model.TrxREJECTED, model.TrxCANCELLED},
+	)
+}
+
+// withHeldAmount selects tenor limits with the total amount of their active limit holds as held_amount
+func withHeldAmount(db *gorm.DB) *gorm.DB {
+	return db.Select(
+		"user_tenor_limits.*, (SELECT COALESCE(SUM(limit_holds.amount), 0) FROM limit_holds "+
+			"WHERE limit_holds.user_id = user_tenor_limits.user_id AND limit_holds.tenor = user_tenor_limits.tenor_in_months "+
+			"AND limit_holds.status = ? AND limit_holds.expires_at > ?) AS held_amount",
+		model.HoldACTIVE, time.Now(),
+	)
+}
+
+// withCreditLineHeldAmount selects credit lines with the total amount of the active limit holds of all tenors as held_amount
+func withCreditLineHeldAmount(db *gorm.DB) *gorm.DB {
+	return db.Select(
+		"user_credit_lines.*, (SELECT COALESCE(SUM(limit_holds.amount), 0) FROM limit_holds "+
+			"WHERE limit_holds.user_id = user_credit_lines.user_id "+
+			"AND limit_holds.status = ? AND limit_holds.expires_at > ?) AS held_amount",
+		model.HoldACTIVE, time.Now(),
+	)
+}
@@ -22,6 +22,7 @@ type UserRepository interface {
 	GetByNIK(ctx context.Context, nik string, opts ...Option) (*model.User, error)
 	Save(ctx context.Context, user *model.User, opts ...Option) error
 	ListTenorLimits(ctx context.Context, userid string, opts ...Option) ([]*model.TenorLimits, error)
+	GetCreditLine(ctx context.Context, userid string, opts ...Option) (*model.CreditLine, error)
 	ListTransactions(ctx context.Context, userid string, opts ...Option) (total int64, transactions []*model.Transaction, err error)
 }
 type userRepositoryImpl struct {
@@ -63,17 +64,26 @@ func (r userRepositoryImpl) Save(ctx context.Context, user *model.User, opts ...
 
 func (r userRepositoryImpl) ListTenorLimits(ctx context.Context, userid string, opts ...Option) ([]*model.TenorLimits, error) {
 	var tenorLimits []*model.TenorLimits
-	if err := r.getDatabase(ctx, opts...).Where("user_id = ?", userid).Order("tenor_in_months asc").Find(&tenorLimits).Error; err != nil {
+	if err := r.getDatabase(ctx, opts...).Scopes(withHeldAmount).Where("user_id = ?", userid).Order("tenor_in_months asc").Find(&tenorLimits).Error; err != nil {
 		return nil, err
 	}
 
 	return tenorLimits, nil
 }
 
+func (r userRepositoryImpl) GetCreditLine(ctx context.Context, userid string, opts ...Option) (*model.CreditLine, error) {
+	var creditLine model.CreditLine
+	if err := r.getDatabase(ctx, opts...).Scopes(withCreditLineHeldAmount).Where("user_id = ?", userid).First(&creditLine).Error; err != nil {
+		return nil, err
+	}
+	return &creditLine, nil
+}
+
 func (r userRepositoryImpl) ListTransactions(ctx context.Context, userid string, opts ...Option) (total int64, transactions []*model.Transaction, err error) {
-	db := r.getDatabase(ctx, opts...).Model(&model.Transaction{}).Where("user_id = ?", userid)
+	db := r.getDatabase(ctx, opts...).Model(&model.Transaction{}).Where("user_id = ?", userid).Session(&gorm.Session{})
 
-	err = db.Count(&total).Error
+	// count all filtered rows, without pagination
+	err = db.Offset(-1).Limit(-1).Count(&total).Error
 	if err != nil {
 		return
 	}
@@ -0,0 +1,25 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/handler"
+	"xyz/internal/middleware"
+	"xyz/internal/repository"
+)
+
+func HoldRouterV1(app *fiber.App, repo repository.RepoRegistry) {
+	routerV1 := app.Group("/v1")
+	h := handler.NewHoldHandler(repo)
+
+	routerV1.Post("/holds", middleware.Authorization, h.Reserve)
+	routerV1.Get("/holds/:id", middleware.Authorization, h.Get)
+	routerV1.Post("/holds/:id/capture", middleware.Authorization, h.Capture)
+	routerV1.Post("/holds/:id/release", middleware.Authorization, h.Release)
+}
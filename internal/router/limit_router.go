@@ -0,0 +1,23 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/handler"
+	"xyz/internal/middleware"
+	"xyz/internal/repository"
+)
+
+func LimitRouterV1(app *fiber.App, repo repository.RepoRegistry) {
+	routerV1 := app.Group("/v1")
+	h := handler.NewLimitHandler(repo)
+
+	routerV1.Get("/user/tenor-limits/:tenor/ledger", middleware.Authorization, h.ListLedger)
+	routerV1.Post("/user/:id/tenor-limits/:tenor/adjustments", middleware.Authorization, h.Adjust)
+}
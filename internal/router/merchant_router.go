@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/handler"
+	"xyz/internal/middleware"
+	"xyz/internal/repository"
+)
+
+func MerchantRouterV1(app *fiber.App, repo repository.RepoRegistry) {
+	routerV1 := app.Group("/v1")
+	h := handler.NewMerchantHandler(repo)
+
+	routerV1.Get("/merchants", middleware.Authorization, h.List)
+	routerV1.Post("/merchants", middleware.Authorization, h.Create)
+	routerV1.Get("/merchants/report", middleware.Authorization, h.Report)
+	routerV1.Get("/merchants/:id", middleware.Authorization, h.Get)
+	routerV1.Put("/merchants/:id", middleware.Authorization, h.Update)
+	routerV1.Delete("/merchants/:id", middleware.Authorization, h.Delete)
+}
@@ -0,0 +1,24 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/handler"
+	"xyz/internal/middleware"
+	"xyz/internal/repository"
+)
+
+func PaymentRouterV1(app *fiber.App, repo repository.RepoRegistry) {
+	routerV1 := app.Group("/v1")
+	h := handler.NewPaymentHandler(repo)
+
+	routerV1.Post("/transactions/:id/payments", middleware.Authorization, h.Create)
+	routerV1.Get("/transactions/:id/settlement-quote", middleware.Authorization, h.SettlementQuote)
+	routerV1.Post("/transactions/:id/settle", middleware.Authorization, h.Settle)
+}
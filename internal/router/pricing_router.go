@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/handler"
+	"xyz/internal/middleware"
+	"xyz/internal/repository"
+)
+
+func PricingRouterV1(app *fiber.App, repo repository.RepoRegistry) {
+	routerV1 := app.Group("/v1")
+	h := handler.NewPricingHandler(repo)
+
+	routerV1.Get("/pricing/versions", middleware.Authorization, h.ListVersions)
+	routerV1.Post("/pricing/versions", middleware.Authorization, h.CreateVersion)
+	routerV1.Get("/asset-categories", middleware.Authorization, h.ListCategories)
+	routerV1.Post("/asset-categories", middleware.Authorization, h.CreateCategory)
+	routerV1.Put("/asset-categories/:code", middleware.Authorization, h.UpdateCategory)
+}
@@ -19,4 +19,13 @@ func TransactionRouterV1(app *fiber.App, repo repository.RepoRegistry) {
 	h := handler.NewTransactionHandler(repo)
 
 	routerV1.Post("/transaction", middleware.Authorization, h.Create)
+	routerV1.Post("/transactions/simulate", middleware.Authorization, h.Simulate)
+	routerV1.Get("/transactions/by-contract/:contract_number", middleware.Authorization, h.GetByContractNumber)
+	routerV1.Get("/transactions/:id", middleware.Authorization, h.Get)
+	routerV1.Post("/transactions/:id/approve", middleware.Authorization, h.Approve)
+	routerV1.Post("/transactions/:id/reject", middleware.Authorization, h.Reject)
+	routerV1.Post("/transactions/:id/cancel", middleware.Authorization, h.Cancel)
+	routerV1.Delete("/transactions/:id", middleware.Authorization, h.Cancel)
+	routerV1.Get("/transactions/:id/installments", middleware.Authorization, h.ListInstallments)
+	routerV1.Get("/transactions/:id/history", middleware.Authorization, h.ListHistory)
 }
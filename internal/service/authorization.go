@@ -0,0 +1,37 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/helper"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+// getAdmin returns the logged user only if the user has admin role
+func getAdmin(ctx context.Context, userRepository repository.UserRepository, span *otel.Span) (*model.User, error) {
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	user, err := userRepository.GetByID(ctx, userid)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "User not found", span)
+	}
+
+	if !user.IsAdmin() {
+		return nil, response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"time"
+	"xyz/internal/repository"
+	"xyz/pkg/contract"
+)
+
+const (
+	// defaultBranch is used when `contract.branch` is not configured
+	defaultBranch = "001"
+	// maxContractAttempts is the number of attempts to create transaction when the contract number already exists
+	maxContractAttempts = 3
+)
+
+// nextContractNumber generates the contract number from `contract.pattern` and the running number of its period.
+//
+// Must be called inside StartTransaction, so the running number is given back when the transaction is rolled back.
+// Every call in the same transaction returns the next running number
+func nextContractNumber(ctx context.Context, transactionRepository repository.TransactionRepository, productCode string, date time.Time) (string, error) {
+	pattern := viper.GetString("contract.pattern")
+	if contract.Validate(pattern) != nil {
+		pattern = contract.DefaultPattern
+	}
+	branch := viper.GetString("contract.branch")
+	if branch == "" {
+		branch = defaultBranch
+	}
+
+	key := contract.Key(pattern, contract.Values{Product: productCode, Branch: branch, Date: date})
+	seq, err := transactionRepository.NextContractSequence(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return contract.Format(key, seq), nil
+}
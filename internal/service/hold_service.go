@@ -0,0 +1,379 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"go.portalnesia.com/nullable"
+	"go.portalnesia.com/utils"
+	"gorm.io/gorm"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/helper"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+// defaultHoldTTL is used when `hold.ttl` is not configured
+const defaultHoldTTL = 15 * time.Minute
+
+// HoldService reserves the user limit before the transaction is created, e.g. on merchant checkout.
+//
+// Active holds are not part of the available limit, but limit_amount is only deducted when the hold is captured
+type HoldService interface {
+	Reserve(ctx context.Context, req dto.HoldRequest) (*model.LimitHold, *model.TenorLimits, error)
+	Get(ctx context.Context, id string) (*model.LimitHold, error)
+	Capture(ctx context.Context, id string) (*model.Transaction, *model.TenorLimits, error)
+	Release(ctx context.Context, id string) (*model.LimitHold, *model.TenorLimits, error)
+	// Expire marks active holds that passed their expiry time as expired
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
+
+type holdServiceImpl struct {
+	userRepository        repository.UserRepository
+	transactionRepository repository.TransactionRepository
+	pricingRepository     repository.PricingRepository
+	merchantRepository    repository.MerchantRepository
+}
+
+func NewHoldService(userRepository repository.UserRepository, transactionRepository repository.TransactionRepository, pricingRepository repository.PricingRepository, merchantRepository repository.MerchantRepository) HoldService {
+	return holdServiceImpl{
+		userRepository:        userRepository,
+		transactionRepository: transactionRepository,
+		pricingRepository:     pricingRepository,
+		merchantRepository:    merchantRepository,
+	}
+}
+
+func (h holdServiceImpl) Reserve(ctx context.Context, req dto.HoldRequest) (*model.LimitHold, *model.TenorLimits, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "HoldService.Reserve")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	merchantID, salesChannel, err := getMerchantOf(ctx, h.merchantRepository, span, req.MerchantID, req.SalesChannel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !req.DownPayment.LessThan(req.OTR) {
+		errFields := response.NewErrorFields([2]string{"down_payment", "Parameter `down_payment` must be less than `otr`"})
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	// check financing rule of the asset category
+	category, err := checkAssetCategory(ctx, h.pricingRepository, span, req.Category, req.Tenor, req.OTR)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// tenor must be offered by the product and configured in the user limits
+	if err = checkTenor(ctx, h.userRepository, h.pricingRepository, span, userid, req.Product, req.Tenor, category); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		hold  *model.LimitHold
+		limit *model.TenorLimits
+	)
+	err = h.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		// get user
+		user, err := h.userRepository.GetByID(ctx, userid)
+		if err != nil {
+			return response.NotfoundHelper(err, "User not found", span)
+		}
+
+		date := time.Now()
+		productCode := req.Product
+		if productCode == "" {
+			productCode = model.DefaultProduct
+		}
+
+		// calculate price of the financed amount with the active pricing version
+		price, version, err := calculatePrice(ctx, h.pricingRepository, productCode, req.Tenor, req.OTR.Sub(req.DownPayment), date)
+		if err != nil {
+			return err
+		}
+		if err = checkDownPayment(req.OTR, req.DownPayment, version, category); err != nil {
+			return err
+		}
+
+		// get credit line and limit, the lock also serializes holds of the same user
+		var creditLine *model.CreditLine
+		creditLine, limit, err = getLimits(ctx, h.transactionRepository, user.ID, req.Tenor, repository.WithLockTable())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+			}
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+
+		// check available limit of both the credit line and the tenor
+		if availableLimit(creditLine, limit).LessThan(price.TotalAmount) {
+			return response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+		}
+
+		hold = &model.LimitHold{
+			ID:                utils.UUID(),
+			UserID:            user.ID,
+			Tenor:             req.Tenor,
+			OTR:               req.OTR,
+			DownPayment:       req.DownPayment,
+			AdminFee:          price.AdminFee,
+			InstallmentAmount: price.InstallmentAmount,
+			InterestAmount:    price.InterestAmount,
+			Amount:            price.TotalAmount,
+			AssetName:         req.AssetName,
+			AssetCategory:     nullable.NewString(req.Category, true, req.Category != ""),
+			ProductCode:       productCode,
+			PricingVersionID:  nullable.NewString(version.ID, true, true),
+			MerchantID:        merchantID,
+			SalesChannel:      salesChannel,
+			Status:            model.HoldACTIVE,
+			ExpiresAt:         date.Add(holdTTL()),
+			CreatedAt:         date,
+			UpdatedAt:         date,
+		}
+		if req.Reference != "" {
+			hold.Reference = nullable.NewString(req.Reference, true, true)
+		}
+		if err = h.transactionRepository.CreateHold(ctx, hold); err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+
+		// the amount is not deducted, but the versions are incremented,
+		// so optimistic debits that read the limits before this hold are retried
+		if err = h.transactionRepository.UpdateCreditLine(ctx, creditLine); err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		if err = h.transactionRepository.UpdateTenorLimit(ctx, limit); err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		limit.HeldAmount = limit.HeldAmount.Add(hold.Amount)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hold, limit, nil
+}
+
+func (h holdServiceImpl) Get(ctx context.Context, id string) (*model.LimitHold, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "HoldService.Get")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	return h.getOwnedHold(ctx, userid, id, span)
+}
+
+func (h holdServiceImpl) Capture(ctx context.Context, id string) (*model.Transaction, *model.TenorLimits, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "HoldService.Capture")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	var (
+		trx   *model.Transaction
+		limit *model.TenorLimits
+	)
+	err := h.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		var (
+			creditLine *model.CreditLine
+			hold       *model.LimitHold
+			errTx      error
+		)
+		creditLine, limit, hold, errTx = h.lockHold(ctx, userid, id, span)
+		if errTx != nil {
+			return errTx
+		}
+
+		// the hold is part of held amount, so it is not checked against the available limit.
+		// Limit can still be lowered after the hold is created
+		if creditLine.LimitAmount.LessThan(hold.Amount) || limit.LimitAmount.LessThan(hold.Amount) {
+			return response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+		}
+
+		date := time.Now()
+		trx = &model.Transaction{
+			ID:                utils.UUID(),
+			UserID:            hold.UserID,
+			OTR:               hold.OTR,
+			DownPayment:       hold.DownPayment,
+			AdminFee:          hold.AdminFee,
+			InstallmentAmount: hold.InstallmentAmount,
+			InterestAmount:    hold.InterestAmount,
+			AssetName:         hold.AssetName,
+			AssetCategory:     hold.AssetCategory,
+			ProductCode:       hold.ProductCode,
+			PricingVersionID:  hold.PricingVersionID,
+			MerchantID:        hold.MerchantID,
+			SalesChannel:      hold.SalesChannel,
+			Tenor:             hold.Tenor,
+			TransactionDate:   date,
+			Status:            model.TrxPENDING,
+			CreatedAt:         date,
+			UpdatedAt:         date,
+		}
+		// the credit line and limit are locked by lockHold
+		if errTx = debitLimit(ctx, h.transactionRepository, creditLine, limit, hold.Amount, ledgerSource{Type: model.LedgerSourceTRANSACTION, ID: trx.ID}, false); errTx != nil {
+			return errTx
+		}
+		if errTx = saveTransaction(ctx, h.transactionRepository, span, trx); errTx != nil {
+			return errTx
+		}
+		limit.HeldAmount = limit.HeldAmount.Sub(hold.Amount)
+
+		hold.Status = model.HoldCAPTURED
+		hold.TransactionID = nullable.NewString(trx.ID, true, true)
+		hold.CapturedAt = nullable.NewTime(date, true, true)
+		if errTx = h.transactionRepository.SaveHold(ctx, hold); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return trx, limit, nil
+}
+
+func (h holdServiceImpl) Release(ctx context.Context, id string) (*model.LimitHold, *model.TenorLimits, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "HoldService.Release")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	var (
+		hold  *model.LimitHold
+		limit *model.TenorLimits
+	)
+	err := h.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		var errTx error
+		_, limit, hold, errTx = h.lockHold(ctx, userid, id, span)
+		if errTx != nil {
+			return errTx
+		}
+
+		hold.Status = model.HoldRELEASED
+		hold.ReleasedAt = nullable.NewTime(time.Now(), true, true)
+		if errTx = h.transactionRepository.SaveHold(ctx, hold); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+		limit.HeldAmount = limit.HeldAmount.Sub(hold.Amount)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hold, limit, nil
+}
+
+func (h holdServiceImpl) Expire(ctx context.Context, now time.Time) (int64, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "HoldService.Expire")
+	defer span.End()
+
+	count, err := h.transactionRepository.ExpireHolds(ctx, now)
+	if err != nil {
+		span.RecordErrorHelper(err, "TransactionRepository.ExpireHolds")
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// getOwnedHold returns limit hold by id only if it belongs to the user.
+//
+// Not found is returned instead of forbidden, so the hold id cannot be enumerated
+func (h holdServiceImpl) getOwnedHold(ctx context.Context, userid, id string, span *otel.Span, opts ...repository.Option) (*model.LimitHold, error) {
+	hold, err := h.transactionRepository.GetHold(ctx, id, opts...)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Limit hold not found", span)
+	}
+
+	if hold.UserID != userid {
+		return nil, response.NotFound("Limit hold not found")
+	}
+
+	return hold, nil
+}
+
+// lockHold locks the credit line, the tenor limit and then the active hold of the user, in the same order as Reserve.
+//
+// Must be called inside StartTransaction
+func (h holdServiceImpl) lockHold(ctx context.Context, userid, id string, span *otel.Span) (*model.CreditLine, *model.TenorLimits, *model.LimitHold, error) {
+	hold, err := h.getOwnedHold(ctx, userid, id, span)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	creditLine, limit, err := getLimits(ctx, h.transactionRepository, hold.UserID, hold.Tenor, repository.WithLockTable())
+	if err != nil {
+		return nil, nil, nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	// read again after the limit is locked, the hold may be captured or released by another request
+	hold, err = h.transactionRepository.GetHold(ctx, id, repository.WithLockTable())
+	if err != nil {
+		return nil, nil, nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	if hold.IsExpired(time.Now()) {
+		return nil, nil, nil, response.ErrorParameter(response.ErrHoldExpired, response.MsgHoldExpired, fiber.StatusUnprocessableEntity)
+	}
+	if hold.Status != model.HoldACTIVE {
+		return nil, nil, nil, response.ErrorParameter(response.ErrInvalidStatus, response.MsgHoldStatus, fiber.StatusUnprocessableEntity)
+	}
+
+	return creditLine, limit, hold, nil
+}
+
+// holdTTL is how long the limit hold is active, configured with `hold.ttl`
+func holdTTL() time.Duration {
+	if ttl := viper.GetDuration("hold.ttl"); ttl > 0 {
+		return ttl
+	}
+	return defaultHoldTTL
+}
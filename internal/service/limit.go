@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"go.portalnesia.com/nullable"
+	"go.portalnesia.com/utils"
+	"time"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/money"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+const (
+	// ReplenishPrincipal restores only the repaid principal to the tenor limit
+	ReplenishPrincipal = "principal"
+	// ReplenishPrincipalInterest restores the repaid principal and interest to the tenor limit
+	ReplenishPrincipalInterest = "principal_interest"
+)
+
+const (
+	// LimitPessimistic locks the credit line and tenor limit with SELECT ... FOR UPDATE until the database transaction ends
+	LimitPessimistic = "pessimistic"
+	// LimitOptimistic reads the credit line and tenor limit without lock and debits them with a conditional update on their version,
+	// the database transaction is retried when another transaction changed the limit first
+	LimitOptimistic = "optimistic"
+)
+
+// defaultLimitRetries is used when `limit.max_retries` is not configured
+const defaultLimitRetries = 3
+
+// ledgerSource is the record that changes the limit, it is saved in the limit ledger
+type ledgerSource struct {
+	Type   string
+	ID     string
+	Reason string
+}
+
+// errLimitConflict is returned by debitLimit when the credit line or tenor limit is changed after it was read
+var errLimitConflict = errors.New("tenor limit is changed by another transaction")
+
+// optimisticLimit returns true if `limit.concurrency` is optimistic, default is pessimistic
+func optimisticLimit() bool {
+	return viper.GetString("limit.concurrency") == LimitOptimistic
+}
+
+// limitReadOptions returns the options to read the tenor limit that is going to be debited
+func limitReadOptions(optimistic bool) []repository.Option {
+	if optimistic {
+		return nil
+	}
+	return []repository.Option{repository.WithLockTable()}
+}
+
+// getLimits reads the credit line and then the tenor limit of the user. The credit line is always read first,
+// so transactions of different tenors lock the rows in the same order and do not deadlock.
+//
+// The repository error is returned as is
+func getLimits(ctx context.Context, transactionRepository repository.TransactionRepository, userid string, tenor int, opts ...repository.Option) (*model.CreditLine, *model.TenorLimits, error) {
+	creditLine, err := transactionRepository.GetCreditLine(ctx, userid, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit, err := transactionRepository.GetLimit(ctx, userid, tenor, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return creditLine, limit, nil
+}
+
+// availableLimit is the amount that a new transaction of the tenor can use,
+// the smaller of the available credit line and the available tenor limit
+func availableLimit(creditLine *model.CreditLine, limit *model.TenorLimits) money.Money {
+	return money.Min(creditLine.Available(), limit.Available())
+}
+
+// debitLimit deducts amount from the credit line and the tenor limit that are read with limitReadOptions,
+// and appends the debit to the limit ledger.
+//
+// Must be called inside StartTransaction, as the last write so the row lock of the update is held shortly
+func debitLimit(ctx context.Context, transactionRepository repository.TransactionRepository, creditLine *model.CreditLine, limit *model.TenorLimits, amount money.Money, source ledgerSource, optimistic bool) error {
+	if !optimistic {
+		creditLine.LimitAmount = creditLine.LimitAmount.Sub(amount)
+		if err := transactionRepository.UpdateCreditLine(ctx, creditLine); err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		limit.LimitAmount = limit.LimitAmount.Sub(amount)
+		if err := transactionRepository.UpdateTenorLimit(ctx, limit); err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+	} else {
+		ok, err := transactionRepository.DebitCreditLine(ctx, creditLine, amount)
+		if err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		if !ok {
+			return errLimitConflict
+		}
+		ok, err = transactionRepository.DebitTenorLimit(ctx, limit, amount)
+		if err != nil {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		if !ok {
+			return errLimitConflict
+		}
+	}
+
+	_, err := recordLedger(ctx, transactionRepository, creditLine, limit, model.LedgerDEBIT, amount, source)
+	return err
+}
+
+// recordLedger appends the change of the credit line and tenor limit to the limit ledger,
+// with the balances after the change. It must be called after both are saved.
+//
+// limit is nil for a change of the credit line only
+func recordLedger(ctx context.Context, transactionRepository repository.TransactionRepository, creditLine *model.CreditLine, limit *model.TenorLimits, entryType string, amount money.Money, source ledgerSource) (*model.LimitLedger, error) {
+	entry := &model.LimitLedger{
+		ID:                     utils.UUID(),
+		UserID:                 creditLine.UserID,
+		Type:                   entryType,
+		SourceType:             source.Type,
+		SourceID:               source.ID,
+		Amount:                 amount,
+		BalanceAfter:           creditLine.LimitAmount,
+		CreditLineBalanceAfter: creditLine.LimitAmount,
+		Reason:                 nullable.NewString(source.Reason, true, source.Reason != ""),
+		CreatedAt:              time.Now(),
+	}
+	if limit != nil {
+		entry.TenorLimitID = nullable.NewString(limit.ID, true, true)
+		entry.Tenor = limit.TenorInMonths
+		entry.BalanceAfter = limit.LimitAmount
+	}
+	if err := transactionRepository.CreateLedger(ctx, entry); err != nil {
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+	return entry, nil
+}
+
+// retryLimitConflict runs the database transaction again while debitLimit returns conflict,
+// up to `limit.max_retries` attempts (default 3)
+func retryLimitConflict(span *otel.Span, fn func() error) error {
+	retries := viper.GetInt("limit.max_retries")
+	if retries <= 0 {
+		retries = defaultLimitRetries
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if !errors.Is(err, errLimitConflict) {
+			return err
+		}
+		span.RecordErrorHelper(err, "debitLimit")
+		if attempt >= retries {
+			return response.ErrorParameter(response.ErrLimitConflict, response.MsgLimitConflict, fiber.StatusConflict)
+		}
+	}
+}
+
+// restoreLimit gives back the amount to the user credit line and tenor limit, and appends the credit to the limit ledger.
+//
+// Must be called inside StartTransaction
+func restoreLimit(ctx context.Context, transactionRepository repository.TransactionRepository, userid string, tenor int, amount money.Money, source ledgerSource) (*model.TenorLimits, error) {
+	creditLine, limit, err := getLimits(ctx, transactionRepository, userid, tenor, repository.WithLockTable())
+	if err != nil {
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	creditLine.LimitAmount = creditLine.LimitAmount.Add(amount)
+	if err = transactionRepository.UpdateCreditLine(ctx, creditLine); err != nil {
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	limit.LimitAmount = limit.LimitAmount.Add(amount)
+	if err = transactionRepository.UpdateTenorLimit(ctx, limit); err != nil {
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	if _, err = recordLedger(ctx, transactionRepository, creditLine, limit, model.LedgerCREDIT, amount, source); err != nil {
+		return nil, err
+	}
+
+	return limit, nil
+}
+
+// replenishAmount calculates the amount that goes back to the tenor limit from the payment allocations,
+// based on `limit.replenish_policy` configuration.
+//
+// The tenor limit is debited with the total amount of the transaction, including interest and admin fee.
+// replenished is the amount that is already restored by earlier payments of the transaction. When the contract is paid off,
+// all of the debited amount that is not restored yet goes back, so a repaid contract never lowers the limit for good
+func replenishAmount(trx *model.Transaction, allocations []*model.PaymentAllocation, replenished money.Money, paidOff bool) money.Money {
+	remaining := money.Max(trx.TotalAmount().Sub(replenished), money.Money{})
+	if paidOff {
+		return remaining
+	}
+
+	withInterest := viper.GetString("limit.replenish_policy") == ReplenishPrincipalInterest
+
+	var amount money.Money
+	for _, alloc := range allocations {
+		amount = amount.Add(alloc.PrincipalAmount)
+		if withInterest {
+			amount = amount.Add(alloc.InterestAmount)
+		}
+	}
+	return money.Min(amount, remaining)
+}
@@ -0,0 +1,288 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/helper"
+	"xyz/pkg/money"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+type LimitService interface {
+	// ListLedger returns the limit ledger of the user tenor limit, the newest entry is the first
+	ListLedger(ctx context.Context, tenor int, req *dto.LedgerListRequest) ([]*model.LimitLedger, *response.Meta, error)
+	// Adjust credits or debits the credit line and tenor limit of the user, only for admin
+	Adjust(ctx context.Context, userId string, tenor int, req dto.LimitAdjustmentRequest) (*model.LimitLedger, error)
+	// Reconcile compares every credit line and tenor limit with its transactions and limit holds, batchSize users at a time.
+	// If apply is true, the drift is corrected with reconciliation entries in the limit ledger
+	Reconcile(ctx context.Context, batchSize int, apply bool) (*dto.LimitReconcileResult, error)
+}
+
+type limitServiceImpl struct {
+	userRepository        repository.UserRepository
+	transactionRepository repository.TransactionRepository
+}
+
+func NewLimitService(userRepository repository.UserRepository, transactionRepository repository.TransactionRepository) LimitService {
+	return limitServiceImpl{
+		userRepository:        userRepository,
+		transactionRepository: transactionRepository,
+	}
+}
+
+func (l limitServiceImpl) ListLedger(ctx context.Context, tenor int, req *dto.LedgerListRequest) ([]*model.LimitLedger, *response.Meta, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "LimitService.ListLedger")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	if _, err := l.transactionRepository.GetLimit(ctx, userid, tenor); err != nil {
+		return nil, nil, response.NotfoundHelper(err, "Tenor limit not found", span)
+	}
+
+	total, entries, err := l.transactionRepository.ListLedger(ctx, userid,
+		repository.WithTenor(tenor),
+		repository.WithType(req.Type),
+		repository.WithSort("", "desc", nil, "created_at"),
+		repository.WithPagination(&req.Pagination),
+	)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.ListLedger")
+		return nil, nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	meta := req.Meta(total)
+
+	return entries, &meta, nil
+}
+
+func (l limitServiceImpl) Adjust(ctx context.Context, userId string, tenor int, req dto.LimitAdjustmentRequest) (*model.LimitLedger, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "LimitService.Adjust")
+	defer span.End()
+
+	admin, err := getAdmin(ctx, l.userRepository, span)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err = validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+	if req.Amount.IsZero() {
+		errFields := response.NewErrorFields([2]string{"amount", "Parameter `amount` must not be zero"})
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	entryType, amount := model.LedgerCREDIT, req.Amount
+	if req.Amount.IsNegative() {
+		entryType, amount = model.LedgerDEBIT, money.Money{}.Sub(req.Amount)
+	}
+
+	var entry *model.LimitLedger
+	err = l.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		creditLine, limit, errTx := getLimits(ctx, l.transactionRepository, userId, tenor, repository.WithLockTable())
+		if errTx != nil {
+			return response.NotfoundHelper(errTx, "Tenor limit not found", span)
+		}
+
+		if entryType == model.LedgerDEBIT {
+			// active limit holds are not available, the held amount must stay covered by the limit
+			if creditLine.Available().LessThan(amount) || limit.Available().LessThan(amount) {
+				errFields := response.NewErrorFields([2]string{"amount", "Debit must not be more than the available tenor limit and credit line"})
+				return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+			}
+			creditLine.LimitAmount = creditLine.LimitAmount.Sub(amount)
+			limit.LimitAmount = limit.LimitAmount.Sub(amount)
+		} else {
+			creditLine.LimitAmount = creditLine.LimitAmount.Add(amount)
+			limit.LimitAmount = limit.LimitAmount.Add(amount)
+		}
+		// adjustment is the only change of the granted limit, it is the base of the limit reconciliation
+		creditLine.GrantedAmount = creditLine.GrantedAmount.Add(req.Amount)
+		limit.GrantedAmount = limit.GrantedAmount.Add(req.Amount)
+
+		if errTx = l.transactionRepository.UpdateCreditLine(ctx, creditLine); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+		if errTx = l.transactionRepository.UpdateTenorLimit(ctx, limit); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		entry, errTx = recordLedger(ctx, l.transactionRepository, creditLine, limit, entryType, amount, ledgerSource{
+			Type:   model.LedgerSourceADJUSTMENT,
+			ID:     admin.ID,
+			Reason: req.Reason,
+		})
+		return errTx
+	})
+	if err != nil {
+		span.RecordErrorHelper(err, "db.transaction")
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (l limitServiceImpl) Reconcile(ctx context.Context, batchSize int, apply bool) (*dto.LimitReconcileResult, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "LimitService.Reconcile")
+	defer span.End()
+
+	result := &dto.LimitReconcileResult{Items: []*model.LimitReconciliation{}}
+	afterUserId := ""
+	for {
+		reconciliations, err := l.transactionRepository.ReconcileLimits(ctx, afterUserId, batchSize)
+		if err != nil {
+			span.RecordErrorHelper(err, "repository.ReconcileLimits")
+			return nil, response.ErrorServer(response.MsgInternalServer, err)
+		}
+		if len(reconciliations) == 0 {
+			break
+		}
+
+		// rows are ordered by user, the limits of one user are corrected together
+		for start := 0; start < len(reconciliations); {
+			userId := reconciliations[start].UserID
+			end := start
+			var drifted []*model.LimitReconciliation
+			for ; end < len(reconciliations) && reconciliations[end].UserID == userId; end++ {
+				result.Scanned++
+				if !reconciliations[end].Drift.IsZero() {
+					drifted = append(drifted, reconciliations[end])
+				}
+			}
+			result.Mismatches += len(drifted)
+			result.Items = append(result.Items, drifted...)
+
+			if apply && len(drifted) > 0 {
+				if err = l.applyReconciliation(ctx, userId, reconciliations[start:end]); err != nil {
+					span.RecordErrorHelper(err, "apply reconciliation "+userId)
+					result.Failed += len(drifted)
+				} else {
+					for _, reconciliation := range drifted {
+						reconciliation.Applied = true
+					}
+					result.Applied += len(drifted)
+				}
+			}
+			start = end
+		}
+		afterUserId = reconciliations[len(reconciliations)-1].UserID
+	}
+
+	return result, nil
+}
+
+// applyReconciliation corrects the drift of the credit line and tenor limits of one user.
+// The limits are locked, credit line first, and the reconciliation is read again,
+// because transactions that are created after the scan change the limits and their records together.
+//
+// Every tenor limit with drift gets a reconciliation entry that moves the tenor limit and the credit line by the same amount,
+// like every other ledger entry. The drift of the credit line that is left gets an entry of the credit line only
+func (l limitServiceImpl) applyReconciliation(ctx context.Context, userId string, scanned []*model.LimitReconciliation) error {
+	return l.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		creditLine, err := l.transactionRepository.GetCreditLine(ctx, userId, repository.WithLockTable())
+		if err != nil {
+			return err
+		}
+		limits := make(map[int]*model.TenorLimits)
+		for _, reconciliation := range scanned {
+			if reconciliation.LimitType != model.ReconcileTENORLIMIT {
+				continue
+			}
+			limit, err := l.transactionRepository.GetLimit(ctx, userId, reconciliation.Tenor, repository.WithLockTable())
+			if err != nil {
+				return err
+			}
+			limits[limit.TenorInMonths] = limit
+		}
+
+		current, err := l.transactionRepository.ListUserLimitReconciliations(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		var creditLineDrift, corrected money.Money
+		for _, reconciliation := range current {
+			if reconciliation.LimitType == model.ReconcileCREDITLINE {
+				creditLineDrift = reconciliation.Drift
+				continue
+			}
+			limit, ok := limits[reconciliation.Tenor]
+			if !ok || reconciliation.Drift.IsZero() {
+				continue
+			}
+
+			change := money.Money{}.Sub(reconciliation.Drift)
+			creditLine.LimitAmount = creditLine.LimitAmount.Add(change)
+			limit.LimitAmount = limit.LimitAmount.Add(change)
+			if err = l.transactionRepository.UpdateCreditLine(ctx, creditLine); err != nil {
+				return err
+			}
+			if err = l.transactionRepository.UpdateTenorLimit(ctx, limit); err != nil {
+				return err
+			}
+			if err = recordReconciliation(ctx, l.transactionRepository, creditLine, limit, change); err != nil {
+				return err
+			}
+			corrected = corrected.Add(reconciliation.Drift)
+		}
+
+		if residual := creditLineDrift.Sub(corrected); !residual.IsZero() {
+			change := money.Money{}.Sub(residual)
+			creditLine.LimitAmount = creditLine.LimitAmount.Add(change)
+			if err = l.transactionRepository.UpdateCreditLine(ctx, creditLine); err != nil {
+				return err
+			}
+			if err = recordReconciliation(ctx, l.transactionRepository, creditLine, nil, change); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// recordReconciliation appends the signed change of the reconciliation to the limit ledger, the source is the corrected limit
+func recordReconciliation(ctx context.Context, transactionRepository repository.TransactionRepository, creditLine *model.CreditLine, limit *model.TenorLimits, change money.Money) error {
+	entryType, amount := model.LedgerCREDIT, change
+	if change.IsNegative() {
+		entryType, amount = model.LedgerDEBIT, money.Money{}.Sub(change)
+	}
+
+	source := ledgerSource{Type: model.LedgerSourceRECONCILIATION, ID: creditLine.ID, Reason: "Limit reconciliation"}
+	if limit != nil {
+		source.ID = limit.ID
+	}
+	_, err := recordLedger(ctx, transactionRepository, creditLine, limit, entryType, amount, source)
+	return err
+}
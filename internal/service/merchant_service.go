@@ -0,0 +1,276 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"go.portalnesia.com/nullable"
+	"go.portalnesia.com/utils"
+	"gorm.io/gorm"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+type MerchantService interface {
+	Create(ctx context.Context, req dto.MerchantRequest) (*model.Merchant, error)
+	Update(ctx context.Context, id string, req dto.MerchantRequest) (*model.Merchant, error)
+	// Delete deactivates the merchant, merchants are never removed because transactions refer to them
+	Delete(ctx context.Context, id string) (*model.Merchant, error)
+	Get(ctx context.Context, id string) (*model.Merchant, error)
+	List(ctx context.Context, req *dto.MerchantListRequest) ([]*model.Merchant, *response.Meta, error)
+	Report(ctx context.Context, req dto.MerchantReportRequest) ([]*model.MerchantReport, error)
+}
+
+type merchantServiceImpl struct {
+	userRepository     repository.UserRepository
+	merchantRepository repository.MerchantRepository
+}
+
+func NewMerchantService(userRepository repository.UserRepository, merchantRepository repository.MerchantRepository) MerchantService {
+	return merchantServiceImpl{
+		userRepository:     userRepository,
+		merchantRepository: merchantRepository,
+	}
+}
+
+func (m merchantServiceImpl) Create(ctx context.Context, req dto.MerchantRequest) (*model.Merchant, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "MerchantService.Create")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, m.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	now := time.Now()
+	merchant := &model.Merchant{
+		ID:        utils.UUID(),
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	setMerchant(merchant, req)
+
+	if err := m.merchantRepository.Create(ctx, merchant); err != nil {
+		return nil, merchantSaveError(err)
+	}
+
+	return merchant, nil
+}
+
+func (m merchantServiceImpl) Update(ctx context.Context, id string, req dto.MerchantRequest) (*model.Merchant, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "MerchantService.Update")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, m.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	merchant, err := m.merchantRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Merchant not found", span)
+	}
+	setMerchant(merchant, req)
+
+	if err = m.merchantRepository.Save(ctx, merchant); err != nil {
+		return nil, merchantSaveError(err)
+	}
+
+	return merchant, nil
+}
+
+func (m merchantServiceImpl) Delete(ctx context.Context, id string) (*model.Merchant, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "MerchantService.Delete")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, m.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	merchant, err := m.merchantRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Merchant not found", span)
+	}
+
+	merchant.Active = false
+	if err = m.merchantRepository.Save(ctx, merchant); err != nil {
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return merchant, nil
+}
+
+func (m merchantServiceImpl) Get(ctx context.Context, id string) (*model.Merchant, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "MerchantService.Get")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, m.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	merchant, err := m.merchantRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Merchant not found", span)
+	}
+
+	return merchant, nil
+}
+
+func (m merchantServiceImpl) List(ctx context.Context, req *dto.MerchantListRequest) ([]*model.Merchant, *response.Meta, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "MerchantService.List")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, m.userRepository, span); err != nil {
+		return nil, nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	total, merchants, err := m.merchantRepository.List(ctx,
+		repository.WithType(req.Type),
+		repository.WithSearch("name", req.Search),
+		repository.WithSort("", "asc", nil, "code"),
+		repository.WithPagination(&req.Pagination),
+	)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.List")
+		return nil, nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	meta := req.Meta(total)
+
+	return merchants, &meta, nil
+}
+
+func (m merchantServiceImpl) Report(ctx context.Context, req dto.MerchantReportRequest) ([]*model.MerchantReport, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "MerchantService.Report")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, m.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	// format is already validated
+	var dateFrom, dateTo time.Time
+	if req.DateFrom != "" {
+		dateFrom, _ = time.ParseInLocation(time.DateOnly, req.DateFrom, time.Local)
+	}
+	if req.DateTo != "" {
+		dateTo, _ = time.ParseInLocation(time.DateOnly, req.DateTo, time.Local)
+	}
+	if !dateFrom.IsZero() && !dateTo.IsZero() && dateFrom.After(dateTo) {
+		errFields := response.NewErrorFields([2]string{"date_from", "Parameter `date_from` must be before `date_to`"})
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	reports, err := m.merchantRepository.Report(ctx, dateFrom, dateTo)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.Report")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return reports, nil
+}
+
+// setMerchant copies the request to the merchant
+func setMerchant(merchant *model.Merchant, req dto.MerchantRequest) {
+	merchant.Code = req.Code
+	merchant.Name = req.Name
+	merchant.Type = req.Type
+	merchant.Address = nullable.NewString(req.Address, true, req.Address != "")
+	if req.Active != nil {
+		merchant.Active = *req.Active
+	}
+}
+
+// merchantSaveError returns field error if the merchant code is already used
+func merchantSaveError(err error) error {
+	if response.IsDuplicateError(err) {
+		errFields := response.NewErrorFields([2]string{"code", "Merchant code is already used"})
+		return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+	return response.ErrorServer(response.MsgInternalServer, err)
+}
+
+// getMerchantOf returns the merchant and sales channel of a new transaction.
+//
+// The merchant must be active. Empty sales channel uses online for online merchants and offline for other merchants,
+// it stays empty if there is no merchant
+func getMerchantOf(ctx context.Context, merchantRepository repository.MerchantRepository, span *otel.Span, merchantId, salesChannel string) (nullable.String, nullable.String, error) {
+	var merchantID, channel nullable.String
+	if salesChannel != "" {
+		channel = nullable.NewString(salesChannel, true, true)
+	}
+	if merchantId == "" {
+		return merchantID, channel, nil
+	}
+
+	merchant, err := merchantRepository.GetByID(ctx, merchantId)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			span.RecordErrorHelper(err, "MerchantRepository.GetByID")
+			return merchantID, channel, response.ErrorServer(response.MsgInternalServer, err)
+		}
+		merchant = nil
+	}
+	if merchant == nil || !merchant.Active {
+		errFields := response.NewErrorFields([2]string{"merchant_id", "Merchant is not found or no longer active"})
+		return merchantID, channel, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	merchantID = nullable.NewString(merchant.ID, true, true)
+	if salesChannel == "" {
+		if merchant.Type == model.MerchantONLINE {
+			channel = nullable.NewString(model.ChannelONLINE, true, true)
+		} else {
+			channel = nullable.NewString(model.ChannelOFFLINE, true, true)
+		}
+	}
+	return merchantID, channel, nil
+}
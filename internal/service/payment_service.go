@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"go.portalnesia.com/nullable"
+	"go.portalnesia.com/utils"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/money"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+type PaymentService interface {
+	Create(ctx context.Context, transactionId string, req dto.PaymentRequest) (*model.Payment, error)
+}
+
+type paymentServiceImpl struct {
+	userRepository        repository.UserRepository
+	transactionRepository repository.TransactionRepository
+	paymentRepository     repository.PaymentRepository
+}
+
+func NewPaymentService(userRepository repository.UserRepository, transactionRepository repository.TransactionRepository, paymentRepository repository.PaymentRepository) PaymentService {
+	return paymentServiceImpl{
+		userRepository:        userRepository,
+		transactionRepository: transactionRepository,
+		paymentRepository:     paymentRepository,
+	}
+}
+
+func (p paymentServiceImpl) Create(ctx context.Context, transactionId string, req dto.PaymentRequest) (*model.Payment, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "PaymentService.Create")
+	defer span.End()
+
+	// only admin can record payment, after the money is received.
+	// Customers cannot record their own payment, because it restores their limit without any money received
+	admin, err := getAdmin(ctx, p.userRepository, span)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err = validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	var payment *model.Payment
+	err = p.paymentRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		// get transaction
+		trx, errTx := p.transactionRepository.GetByID(ctx, transactionId, repository.WithLockTable())
+		if errTx != nil {
+			return response.NotfoundHelper(errTx, "Transaction not found", span)
+		}
+
+		// only approved transaction can be paid
+		if trx.Status != model.TrxAPPROVED {
+			return response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+		}
+
+		// get installments
+		installments, errTx := p.transactionRepository.ListInstallments(ctx, trx.ID, repository.WithLockTable())
+		if errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		date := time.Now()
+		payment = &model.Payment{
+			ID:            utils.UUID(),
+			TransactionID: trx.ID,
+			UserID:        trx.UserID,
+			Amount:        req.Amount,
+			RecordedBy:    admin.ID,
+			PaidAt:        date,
+			CreatedAt:     date,
+			UpdatedAt:     date,
+		}
+		if req.Reference != "" {
+			payment.Reference = nullable.NewString(req.Reference, true, true)
+		}
+
+		var (
+			paidInstallments []*model.Installment
+			excess           money.Money
+		)
+		payment.Allocations, paidInstallments, excess = allocatePayment(payment.ID, installments, req.Amount, date)
+		if len(payment.Allocations) == 0 {
+			return response.ErrorParameter(response.ErrNoOutstanding, response.MsgNoOutstanding, fiber.StatusUnprocessableEntity)
+		}
+		payment.ExcessAmount = excess
+		payment.AppliedAmount = req.Amount.Sub(excess)
+
+		// the contract is paid off when every installment is closed by this payment
+		paidOff := true
+		for _, inst := range installments {
+			if !inst.IsClosed() {
+				paidOff = false
+				break
+			}
+		}
+
+		replenished, errTx := p.paymentRepository.SumReplenished(ctx, trx.ID)
+		if errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// replenish tenor limit with the repaid amount
+		payment.ReplenishedAmount = replenishAmount(trx, payment.Allocations, replenished, paidOff)
+		if payment.ReplenishedAmount.IsPositive() {
+			if _, errTx = restoreLimit(ctx, p.transactionRepository, trx.UserID, trx.Tenor, payment.ReplenishedAmount, ledgerSource{Type: model.LedgerSourcePAYMENT, ID: payment.ID}); errTx != nil {
+				return errTx
+			}
+		}
+
+		// save payment
+		if errTx = p.paymentRepository.Create(ctx, payment); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// save allocations
+		if errTx = p.paymentRepository.CreateAllocations(ctx, payment.Allocations); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// update installments
+		if errTx = p.transactionRepository.SaveInstallments(ctx, paidInstallments); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// allocatePayment applies amount to the oldest unpaid installment first.
+//
+// In every installment, the amount is applied to late payment penalty, admin fee, interest and then principal.
+// It returns the allocations, the updated installments and the excess amount that cannot be allocated
+func allocatePayment(paymentId string, installments []*model.Installment, amount money.Money, date time.Time) (allocations []*model.PaymentAllocation, updated []*model.Installment, excess money.Money) {
+	remaining := amount
+
+	pay := func(due, paid money.Money) money.Money {
+		v := money.Max(money.Min(remaining, due.Sub(paid)), money.Money{})
+		remaining = remaining.Sub(v)
+		return v
+	}
+
+	for _, inst := range installments {
+		if !remaining.IsPositive() {
+			break
+		}
+		if inst.IsClosed() || !inst.Outstanding().IsPositive() {
+			continue
+		}
+
+		alloc := &model.PaymentAllocation{
+			ID:            utils.UUID(),
+			PaymentID:     paymentId,
+			InstallmentID: nullable.NewString(inst.ID, true, true),
+			CreatedAt:     date,
+		}
+		alloc.PenaltyAmount = pay(inst.PenaltyAmount, inst.PenaltyPaid)
+		alloc.FeeAmount = pay(inst.FeeAmount, inst.FeePaid)
+		alloc.InterestAmount = pay(inst.InterestAmount, inst.InterestPaid)
+		alloc.PrincipalAmount = pay(inst.PrincipalAmount, inst.PrincipalPaid)
+		alloc.Amount = money.Sum(alloc.PenaltyAmount, alloc.FeeAmount, alloc.InterestAmount, alloc.PrincipalAmount)
+
+		inst.PenaltyPaid = inst.PenaltyPaid.Add(alloc.PenaltyAmount)
+		inst.FeePaid = inst.FeePaid.Add(alloc.FeeAmount)
+		inst.InterestPaid = inst.InterestPaid.Add(alloc.InterestAmount)
+		inst.PrincipalPaid = inst.PrincipalPaid.Add(alloc.PrincipalAmount)
+		inst.AmountPaid = inst.AmountPaid.Add(alloc.Amount)
+		inst.UpdatedAt = date
+		if !inst.Outstanding().IsPositive() {
+			inst.Status = model.InstallmentPAID
+			inst.PaidAt = nullable.NewTime(date, true, true)
+		} else {
+			inst.Status = model.InstallmentPARTIAL
+		}
+
+		allocations = append(allocations, alloc)
+		updated = append(updated, inst)
+	}
+
+	return allocations, updated, remaining
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/dromara/carbon/v2"
+	"github.com/spf13/viper"
+	"go.portalnesia.com/utils"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/money"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+)
+
+const (
+	// PenaltyPercent charges `penalty.value` percent of the unpaid installment per day
+	PenaltyPercent = "percent"
+	// PenaltyFlat charges `penalty.value` amount per day
+	PenaltyFlat = "flat"
+)
+
+// errPenaltySkipped is returned when the installment doesn't need penalty on the date
+var errPenaltySkipped = errors.New("penalty skipped")
+
+type PenaltyService interface {
+	Accrue(ctx context.Context, date time.Time) (*dto.PenaltyAccrualResult, error)
+}
+
+type penaltyServiceImpl struct {
+	transactionRepository repository.TransactionRepository
+}
+
+func NewPenaltyService(transactionRepository repository.TransactionRepository) PenaltyService {
+	return penaltyServiceImpl{
+		transactionRepository: transactionRepository,
+	}
+}
+
+// Accrue calculates late payment penalty of every overdue installment on the date.
+//
+// Every installment is processed in its own database transaction, and the penalty of the same date is never applied twice
+func (p penaltyServiceImpl) Accrue(ctx context.Context, date time.Time) (*dto.PenaltyAccrualResult, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "PenaltyService.Accrue")
+	defer span.End()
+
+	date = carbon.CreateFromStdTime(date).StartOfDay().StdTime()
+
+	installments, err := p.transactionRepository.ListOverdueInstallments(ctx, date)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.ListOverdueInstallments")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	result := &dto.PenaltyAccrualResult{
+		Date:    date.Format(time.DateOnly),
+		Overdue: len(installments),
+	}
+	for _, inst := range installments {
+		var penalty *model.InstallmentPenalty
+		errTx := p.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+			var errTx error
+			penalty, errTx = p.accrueInstallment(ctx, inst, date)
+			return errTx
+		})
+		if errors.Is(errTx, errPenaltySkipped) {
+			result.Skipped++
+			continue
+		}
+		if errTx != nil {
+			span.RecordErrorHelper(errTx, "accrue installment "+inst.ID)
+			result.Failed++
+			continue
+		}
+		result.Accrued++
+		result.TotalAmount = result.TotalAmount.Add(penalty.Amount)
+	}
+
+	return result, nil
+}
+
+// accrueInstallment locks the transaction before the installment, in the same order as payment and settlement,
+// so a penalty run during a payment does not deadlock
+func (p penaltyServiceImpl) accrueInstallment(ctx context.Context, overdue *model.Installment, date time.Time) (*model.InstallmentPenalty, error) {
+	if _, err := p.transactionRepository.GetByID(ctx, overdue.TransactionID, repository.WithLockTable()); err != nil {
+		return nil, err
+	}
+
+	inst, err := p.transactionRepository.GetInstallment(ctx, overdue.ID, repository.WithLockTable())
+	if err != nil {
+		return nil, err
+	}
+
+	daysOverdue := int(date.Sub(carbon.CreateFromStdTime(inst.DueDate).StartOfDay().StdTime()).Hours() / 24)
+	if inst.IsClosed() || daysOverdue < 1 {
+		return nil, errPenaltySkipped
+	}
+
+	penalty := &model.InstallmentPenalty{
+		ID:            utils.UUID(),
+		InstallmentID: inst.ID,
+		TransactionID: inst.TransactionID,
+		AccrualDate:   date,
+		DaysOverdue:   daysOverdue,
+		BaseAmount:    inst.OutstandingDue(),
+		CreatedAt:     time.Now(),
+	}
+	penalty.Amount = calculatePenalty(inst)
+	if !penalty.Amount.IsPositive() {
+		return nil, errPenaltySkipped
+	}
+
+	created, err := p.transactionRepository.CreatePenalty(ctx, penalty)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// already accrued on the same date
+		return nil, errPenaltySkipped
+	}
+
+	inst.PenaltyAmount = inst.PenaltyAmount.Add(penalty.Amount)
+	inst.UpdatedAt = penalty.CreatedAt
+	if err = p.transactionRepository.SaveInstallments(ctx, []*model.Installment{inst}); err != nil {
+		return nil, err
+	}
+
+	if err = p.transactionRepository.AddTransactionPenalty(ctx, inst.TransactionID, penalty.Amount); err != nil {
+		return nil, err
+	}
+
+	return penalty, nil
+}
+
+// calculatePenalty calculates one day penalty of the installment.
+//
+// Configurations:
+// - penalty.type: percent or flat
+// - penalty.value: percent of the unpaid installment or flat amount per day
+// - penalty.max_percent: maximum total penalty in percent of the installment amount, 0 to disable
+func calculatePenalty(inst *model.Installment) money.Money {
+	value := viper.GetFloat64("penalty.value")
+
+	var amount money.Money
+	switch viper.GetString("penalty.type") {
+	case PenaltyFlat:
+		amount = money.FromFloat(value)
+	case PenaltyPercent:
+		amount = inst.OutstandingDue().Percent(value)
+	}
+
+	if maxPercent := viper.GetFloat64("penalty.max_percent"); maxPercent > 0 {
+		maxAmount := inst.AmountDue.Percent(maxPercent).Sub(inst.PenaltyAmount)
+		amount = money.Min(amount, maxAmount)
+	}
+
+	return money.Max(amount, money.Money{})
+}
@@ -0,0 +1,435 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"go.portalnesia.com/nullable"
+	"go.portalnesia.com/utils"
+	"gorm.io/gorm"
+	"slices"
+	"strconv"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/helper"
+	"xyz/pkg/money"
+	"xyz/pkg/otel"
+	"xyz/pkg/pricing"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+type PricingService interface {
+	CreateVersion(ctx context.Context, req dto.PricingVersionRequest) (*model.PricingVersion, error)
+	ListVersions(ctx context.Context, req dto.PricingListRequest) ([]*model.PricingVersion, error)
+	CreateCategory(ctx context.Context, req dto.AssetCategoryRequest) (*model.AssetCategory, error)
+	UpdateCategory(ctx context.Context, code string, req dto.AssetCategoryRequest) (*model.AssetCategory, error)
+	// ListCategories returns active asset categories for users, and all asset categories for admin
+	ListCategories(ctx context.Context) ([]*model.AssetCategory, error)
+}
+
+type pricingServiceImpl struct {
+	userRepository    repository.UserRepository
+	pricingRepository repository.PricingRepository
+}
+
+func NewPricingService(userRepository repository.UserRepository, pricingRepository repository.PricingRepository) PricingService {
+	return pricingServiceImpl{
+		userRepository:    userRepository,
+		pricingRepository: pricingRepository,
+	}
+}
+
+func (p pricingServiceImpl) CreateVersion(ctx context.Context, req dto.PricingVersionRequest) (*model.PricingVersion, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "PricingService.CreateVersion")
+	defer span.End()
+
+	admin, err := getAdmin(ctx, p.userRepository, span)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err = validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	// next validation
+	errs := response.NewErrorFields()
+
+	// versions can only be added for the future, so the price of existing transactions never changes
+	effectiveFrom, _ := time.ParseInLocation(time.DateOnly, req.EffectiveFrom, time.Local)
+	now := time.Now()
+	if effectiveFrom.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)) {
+		errs.Add("effective_from", "Parameter `effective_from` cannot be in the past")
+	}
+	if req.AdminFeeMax.IsPositive() && req.AdminFeeMax.LessThan(req.AdminFeeMin) {
+		errs.Add("admin_fee_max", "Parameter `admin_fee_max` must be greater than `admin_fee_min`")
+	}
+	tenors := make(map[int]bool)
+	for _, rate := range req.Rates {
+		if tenors[rate.Tenor] {
+			errs.Add("rates", fmt.Sprintf("Duplicate tenor %d", rate.Tenor))
+		}
+		tenors[rate.Tenor] = true
+	}
+	if errs.Exist() {
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errs)
+	}
+
+	version := &model.PricingVersion{
+		ID:                    utils.UUID(),
+		ProductCode:           req.ProductCode,
+		Version:               1,
+		EffectiveFrom:         effectiveFrom,
+		InterestMethod:        req.InterestMethod,
+		AdminFeeType:          req.AdminFeeType,
+		AdminFeeValue:         req.AdminFeeValue,
+		AdminFeeBase:          req.AdminFeeBase,
+		AdminFeeMin:           req.AdminFeeMin,
+		AdminFeeMax:           req.AdminFeeMax,
+		MinDownPaymentPercent: req.MinDownPaymentPercent,
+		CreatedBy:             nullable.NewString(admin.ID, true, true),
+		CreatedAt:             now,
+	}
+	if version.AdminFeeBase == "" {
+		version.AdminFeeBase = pricing.FeeBasePrincipal
+	}
+	for _, rate := range req.Rates {
+		version.Rates = append(version.Rates, &model.PricingRate{
+			ID:               utils.UUID(),
+			PricingVersionID: version.ID,
+			Tenor:            rate.Tenor,
+			InterestRate:     rate.InterestRate,
+		})
+	}
+
+	err = p.pricingRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		// get next version number
+		latest, errTx := p.pricingRepository.GetLatestVersion(ctx, req.ProductCode, repository.WithLockTable())
+		if errTx != nil && !errors.Is(errTx, gorm.ErrRecordNotFound) {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+		if latest != nil {
+			version.Version = latest.Version + 1
+		}
+
+		if errTx = p.pricingRepository.CreateVersion(ctx, version); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		if errTx = p.pricingRepository.CreateRates(ctx, version.Rates); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+func (p pricingServiceImpl) ListVersions(ctx context.Context, req dto.PricingListRequest) ([]*model.PricingVersion, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "PricingService.ListVersions")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, p.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	productCode := req.ProductCode
+	if productCode == "" {
+		productCode = model.DefaultProduct
+	}
+
+	versions, err := p.pricingRepository.ListVersions(ctx, productCode)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.ListVersions")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return versions, nil
+}
+
+func (p pricingServiceImpl) CreateCategory(ctx context.Context, req dto.AssetCategoryRequest) (*model.AssetCategory, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "PricingService.CreateCategory")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, p.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	if err := validateCategory(req, span); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	category := &model.AssetCategory{
+		ID:        utils.UUID(),
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	setCategory(category, req)
+
+	if err := p.pricingRepository.CreateCategory(ctx, category); err != nil {
+		return nil, categorySaveError(err)
+	}
+
+	return category, nil
+}
+
+func (p pricingServiceImpl) UpdateCategory(ctx context.Context, code string, req dto.AssetCategoryRequest) (*model.AssetCategory, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "PricingService.UpdateCategory")
+	defer span.End()
+
+	if _, err := getAdmin(ctx, p.userRepository, span); err != nil {
+		return nil, err
+	}
+
+	if err := validateCategory(req, span); err != nil {
+		return nil, err
+	}
+
+	category, err := p.pricingRepository.GetCategory(ctx, code)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Asset category not found", span)
+	}
+	setCategory(category, req)
+
+	if err = p.pricingRepository.SaveCategory(ctx, category); err != nil {
+		return nil, categorySaveError(err)
+	}
+
+	return category, nil
+}
+
+func (p pricingServiceImpl) ListCategories(ctx context.Context) ([]*model.AssetCategory, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "PricingService.ListCategories")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	user, err := p.userRepository.GetByID(ctx, userid)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "User not found", span)
+	}
+
+	var opts []repository.Option
+	if !user.IsAdmin() {
+		opts = append(opts, repository.WithActive())
+	}
+
+	categories, err := p.pricingRepository.ListCategories(ctx, opts...)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.ListCategories")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return categories, nil
+}
+
+// validateCategory validates asset category request
+func validateCategory(req dto.AssetCategoryRequest, span *otel.Span) error {
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	// next validation
+	errs := response.NewErrorFields()
+	if req.MaxOTR.IsPositive() && req.MaxOTR.LessThan(req.MinOTR) {
+		errs.Add("max_otr", "Parameter `max_otr` must be greater than `min_otr`")
+	}
+	tenors := make(map[int]bool)
+	for _, tenor := range req.Tenors {
+		if tenors[tenor] {
+			errs.Add("tenors", fmt.Sprintf("Duplicate tenor %d", tenor))
+		}
+		tenors[tenor] = true
+	}
+	if errs.Exist() {
+		return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errs)
+	}
+
+	return nil
+}
+
+// setCategory copies the request to the asset category, tenors are sorted
+func setCategory(category *model.AssetCategory, req dto.AssetCategoryRequest) {
+	category.Code = req.Code
+	category.Name = req.Name
+	category.Tenors = slices.Sorted(slices.Values(req.Tenors))
+	category.MinOTR = req.MinOTR
+	category.MaxOTR = req.MaxOTR
+	category.MinDownPaymentPercent = req.MinDownPaymentPercent
+	if req.Active != nil {
+		category.Active = *req.Active
+	}
+}
+
+// categorySaveError returns field error if the asset category code is already used
+func categorySaveError(err error) error {
+	if response.IsDuplicateError(err) {
+		errFields := response.NewErrorFields([2]string{"code", "Asset category code is already used"})
+		return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+	return response.ErrorServer(response.MsgInternalServer, err)
+}
+
+// checkAssetCategory checks the tenor and OTR of a new transaction against the financing rule of the asset category.
+// Empty category code is not checked.
+//
+// All violated rules are returned as field errors
+func checkAssetCategory(ctx context.Context, pricingRepository repository.PricingRepository, span *otel.Span, code string, tenor int, otr money.Money) (*model.AssetCategory, error) {
+	category, err := getAssetCategory(ctx, pricingRepository, span, code)
+	if err != nil || category == nil {
+		return nil, err
+	}
+
+	if err = validateAssetCategory(category, tenor, otr); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// getAssetCategory returns the active asset category of a new transaction, it returns nil for empty category code
+func getAssetCategory(ctx context.Context, pricingRepository repository.PricingRepository, span *otel.Span, code string) (*model.AssetCategory, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	category, err := pricingRepository.GetCategory(ctx, code)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			span.RecordErrorHelper(err, "PricingRepository.GetCategory")
+			return nil, response.ErrorServer(response.MsgInternalServer, err)
+		}
+		category = nil
+	}
+	if category == nil || !category.Active {
+		errFields := response.NewErrorFields([2]string{"category", "Asset category is not found or no longer active"})
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	return category, nil
+}
+
+// validateAssetCategory checks the tenor and OTR against the financing rule of the category.
+// Zero tenor is not checked, simulation checks every tenor on its own
+func validateAssetCategory(category *model.AssetCategory, tenor int, otr money.Money) error {
+	errs := response.NewErrorFields()
+	if tenor != 0 && !category.Tenors.Contains(tenor) {
+		errs.Add("tenor", categoryTenorMessage(category))
+	}
+	if otr.LessThan(category.MinOTR) {
+		errs.Add("otr", fmt.Sprintf("OTR of %s must be at least %s", category.Name, category.MinOTR))
+	}
+	if category.MaxOTR.IsPositive() && otr.GreaterThan(category.MaxOTR) {
+		errs.Add("otr", fmt.Sprintf("OTR of %s must be at most %s", category.Name, category.MaxOTR))
+	}
+	if errs.Exist() {
+		return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errs)
+	}
+	return nil
+}
+
+// categoryTenorMessage is the error message of a tenor that is not allowed by the asset category
+func categoryTenorMessage(category *model.AssetCategory) string {
+	return fmt.Sprintf("Tenor of %s must be one of %s months", category.Name, category.Tenors)
+}
+
+// checkDownPayment checks the down payment of a new transaction against the minimum percent of OTR.
+// The higher minimum of the pricing version and the asset category is used, category can be nil
+func checkDownPayment(otr, downPayment money.Money, version *model.PricingVersion, category *model.AssetCategory) error {
+	minPercent := version.MinDownPaymentPercent
+	if category != nil && category.MinDownPaymentPercent > minPercent {
+		minPercent = category.MinDownPaymentPercent
+	}
+	if minPercent <= 0 {
+		return nil
+	}
+
+	if minimum := otr.Percent(minPercent); downPayment.LessThan(minimum) {
+		errFields := response.NewErrorFields([2]string{"down_payment", fmt.Sprintf("Down payment must be at least %s%% of OTR (%s)", strconv.FormatFloat(minPercent, 'f', -1, 64), minimum)})
+		return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+	return nil
+}
+
+// calculatePrice calculates the transaction amount of principal,
+// using the pricing version of the product that is active at date
+func calculatePrice(ctx context.Context, pricingRepository repository.PricingRepository, productCode string, tenor int, principal money.Money, date time.Time) (pricing.Result, *model.PricingVersion, error) {
+	version, err := pricingRepository.GetActiveVersion(ctx, productCode, date)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pricing.Result{}, nil, response.ErrorParameter(response.ErrPricingNotFound, response.MsgPricingNotFound, fiber.StatusUnprocessableEntity)
+		}
+		return pricing.Result{}, nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	rate, err := pricingRepository.GetRate(ctx, version.ID, tenor)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pricing.Result{}, nil, response.ErrorParameter(response.ErrPricingNotFound, response.MsgPricingNotFound, fiber.StatusUnprocessableEntity)
+		}
+		return pricing.Result{}, nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	rule := version.Rule(*rate)
+	rule.Rounding = installmentRounding()
+	return pricing.Calculate(principal, tenor, rule), version, nil
+}
+
+// installmentRounding returns the rounding policy of monthly installment from configuration.
+//
+// `installment.rounding_unit` is 1, 100 or 1000 rupiah (default 1), `installment.rounding_mode` is
+// up, down or half_even (default) and `installment.residual` is first or last (default)
+func installmentRounding() money.Rounding {
+	rounding := money.Rounding{
+		Unit:     money.New(1),
+		Mode:     money.RoundHalfEven,
+		Residual: money.ResidualLast,
+	}
+
+	switch unit := viper.GetInt64("installment.rounding_unit"); unit {
+	case 1, 100, 1000:
+		rounding.Unit = money.New(unit)
+	}
+	switch mode := money.RoundingMode(viper.GetString("installment.rounding_mode")); mode {
+	case money.RoundUp, money.RoundDown, money.RoundHalfEven:
+		rounding.Mode = mode
+	}
+	if viper.GetString("installment.residual") == money.ResidualFirst {
+		rounding.Residual = money.ResidualFirst
+	}
+	return rounding
+}
@@ -0,0 +1,347 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"go.portalnesia.com/nullable"
+	"go.portalnesia.com/utils"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/pkg/helper"
+	"xyz/pkg/money"
+	"xyz/pkg/otel"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+const (
+	// SettlementWaiveInterest waives the interest of installments that are not due yet
+	SettlementWaiveInterest = "waive"
+	// SettlementChargeInterest charges all remaining interest
+	SettlementChargeInterest = "charge"
+
+	defaultQuoteTTL = 30 * time.Minute
+)
+
+type SettlementService interface {
+	Quote(ctx context.Context, transactionId string) (*model.SettlementQuote, error)
+	Settle(ctx context.Context, transactionId string, req dto.SettleRequest) (*model.Payment, error)
+}
+
+type settlementServiceImpl struct {
+	userRepository        repository.UserRepository
+	transactionRepository repository.TransactionRepository
+	paymentRepository     repository.PaymentRepository
+}
+
+func NewSettlementService(userRepository repository.UserRepository, transactionRepository repository.TransactionRepository, paymentRepository repository.PaymentRepository) SettlementService {
+	return settlementServiceImpl{
+		userRepository:        userRepository,
+		transactionRepository: transactionRepository,
+		paymentRepository:     paymentRepository,
+	}
+}
+
+func (s settlementServiceImpl) Quote(ctx context.Context, transactionId string) (*model.SettlementQuote, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "SettlementService.Quote")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	trx, err := s.getTransaction(ctx, userid, transactionId, span)
+	if err != nil {
+		return nil, err
+	}
+
+	installments, err := s.transactionRepository.ListInstallments(ctx, trx.ID)
+	if err != nil {
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	date := time.Now()
+	quote, _ := calculateSettlement(installments, date)
+	if !quote.TotalAmount.IsPositive() {
+		return nil, response.ErrorParameter(response.ErrNoOutstanding, response.MsgNoOutstanding, fiber.StatusUnprocessableEntity)
+	}
+
+	ttl := viper.GetDuration("settlement.quote_ttl")
+	if ttl <= 0 {
+		ttl = defaultQuoteTTL
+	}
+
+	quote.ID = utils.UUID()
+	quote.TransactionID = trx.ID
+	quote.UserID = trx.UserID
+	quote.Status = model.QuoteACTIVE
+	quote.ExpiresAt = date.Add(ttl)
+	quote.CreatedAt = date
+	quote.UpdatedAt = date
+
+	if err = s.paymentRepository.CreateQuote(ctx, quote); err != nil {
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return quote, nil
+}
+
+func (s settlementServiceImpl) Settle(ctx context.Context, transactionId string, req dto.SettleRequest) (*model.Payment, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "SettlementService.Settle")
+	defer span.End()
+
+	// only admin can settle, after the payoff money is received.
+	// Customers cannot settle by themselves, because settlement closes the installments and restores their limit
+	admin, err := getAdmin(ctx, s.userRepository, span)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err = validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	var payment *model.Payment
+	err = s.paymentRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		// get transaction
+		trx, errTx := s.getSettleableTransaction(ctx, transactionId, span, repository.WithLockTable())
+		if errTx != nil {
+			return errTx
+		}
+
+		// get quote
+		quote, errTx := s.paymentRepository.GetQuote(ctx, req.QuoteID, repository.WithLockTable())
+		if errTx != nil {
+			return response.NotfoundHelper(errTx, "Settlement quote not found", span)
+		}
+		if quote.TransactionID != trx.ID {
+			return response.NotFound("Settlement quote not found")
+		}
+
+		date := time.Now()
+		if quote.IsExpired(date) {
+			return response.ErrorParameter(response.ErrQuoteExpired, response.MsgQuoteExpired, fiber.StatusUnprocessableEntity)
+		}
+		if req.Amount.LessThan(quote.TotalAmount) {
+			return response.ErrorParameter(response.ErrInsufficientPay, response.MsgInsufficientPay, fiber.StatusUnprocessableEntity)
+		}
+
+		// get installments
+		installments, errTx := s.transactionRepository.ListInstallments(ctx, trx.ID, repository.WithLockTable())
+		if errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// the outstanding might change after the quote is created, e.g. new penalty is accrued
+		current, items := calculateSettlement(installments, quote.CreatedAt)
+		if current.TotalAmount != quote.TotalAmount {
+			return response.ErrorParameter(response.ErrQuoteExpired, response.MsgQuoteExpired, fiber.StatusUnprocessableEntity)
+		}
+
+		payment = &model.Payment{
+			ID:            utils.UUID(),
+			TransactionID: trx.ID,
+			UserID:        trx.UserID,
+			Amount:        req.Amount,
+			AppliedAmount: quote.TotalAmount,
+			ExcessAmount:  req.Amount.Sub(quote.TotalAmount),
+			RecordedBy:    admin.ID,
+			PaidAt:        date,
+			CreatedAt:     date,
+			UpdatedAt:     date,
+		}
+		if req.Reference != "" {
+			payment.Reference = nullable.NewString(req.Reference, true, true)
+		}
+
+		settled := make([]*model.Installment, 0, len(items))
+		for _, item := range items {
+			alloc := &model.PaymentAllocation{
+				ID:              utils.UUID(),
+				PaymentID:       payment.ID,
+				InstallmentID:   nullable.NewString(item.installment.ID, true, true),
+				PenaltyAmount:   item.penalty,
+				FeeAmount:       item.fee,
+				InterestAmount:  item.interest,
+				PrincipalAmount: item.principal,
+				Amount:          money.Sum(item.penalty, item.fee, item.interest, item.principal),
+				CreatedAt:       date,
+			}
+
+			inst := item.installment
+			inst.PenaltyPaid = inst.PenaltyPaid.Add(alloc.PenaltyAmount)
+			inst.FeePaid = inst.FeePaid.Add(alloc.FeeAmount)
+			inst.InterestPaid = inst.InterestPaid.Add(alloc.InterestAmount)
+			inst.PrincipalPaid = inst.PrincipalPaid.Add(alloc.PrincipalAmount)
+			inst.AmountPaid = inst.AmountPaid.Add(alloc.Amount)
+			inst.Status = model.InstallmentSETTLED
+			inst.PaidAt = nullable.NewTime(date, true, true)
+			inst.UpdatedAt = date
+
+			payment.Allocations = append(payment.Allocations, alloc)
+			settled = append(settled, inst)
+		}
+
+		// early termination fee is not part of any installment, so it is recorded as its own allocation
+		if current.TerminationFee.IsPositive() {
+			payment.Allocations = append(payment.Allocations, &model.PaymentAllocation{
+				ID:                   utils.UUID(),
+				PaymentID:            payment.ID,
+				TerminationFeeAmount: current.TerminationFee,
+				Amount:               current.TerminationFee,
+				CreatedAt:            date,
+			})
+		}
+
+		// every rupiah of the applied amount must be allocated
+		var allocated money.Money
+		for _, alloc := range payment.Allocations {
+			allocated = allocated.Add(alloc.Amount)
+		}
+		if allocated != payment.AppliedAmount {
+			return response.ErrorServer(response.MsgInternalServer, fmt.Errorf("allocated amount %s does not match applied amount %s", allocated, payment.AppliedAmount))
+		}
+
+		replenished, errTx := s.paymentRepository.SumReplenished(ctx, trx.ID)
+		if errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// settlement closes the contract, so all of the debited amount that is not restored yet goes back to the tenor limit
+		payment.ReplenishedAmount = replenishAmount(trx, payment.Allocations, replenished, true)
+		if payment.ReplenishedAmount.IsPositive() {
+			if _, errTx = restoreLimit(ctx, s.transactionRepository, trx.UserID, trx.Tenor, payment.ReplenishedAmount, ledgerSource{Type: model.LedgerSourcePAYMENT, ID: payment.ID}); errTx != nil {
+				return errTx
+			}
+		}
+
+		// save payment
+		if errTx = s.paymentRepository.Create(ctx, payment); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// save allocations
+		if errTx = s.paymentRepository.CreateAllocations(ctx, payment.Allocations); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// close installments
+		if errTx = s.transactionRepository.SaveInstallments(ctx, settled); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// mark quote as used
+		quote.Status = model.QuoteUSED
+		quote.PaymentID = nullable.NewString(payment.ID, true, true)
+		if errTx = s.paymentRepository.SaveQuote(ctx, quote); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		// close transaction
+		from := trx.Status
+		trx.Status = model.TrxSETTLED
+		if errTx = s.transactionRepository.Save(ctx, trx); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		return recordStatus(ctx, s.transactionRepository, trx, from, admin.ID, "", date)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// getTransaction get approved transaction that owned by the user or any transaction for admin
+func (s settlementServiceImpl) getTransaction(ctx context.Context, userid, transactionId string, span *otel.Span, opts ...repository.Option) (*model.Transaction, error) {
+	trx, err := s.getSettleableTransaction(ctx, transactionId, span, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if trx.UserID != userid {
+		if _, err = getAdmin(ctx, s.userRepository, span); err != nil {
+			return nil, err
+		}
+	}
+
+	return trx, nil
+}
+
+// getSettleableTransaction get transaction that can be settled, without checking the owner
+func (s settlementServiceImpl) getSettleableTransaction(ctx context.Context, transactionId string, span *otel.Span, opts ...repository.Option) (*model.Transaction, error) {
+	trx, err := s.transactionRepository.GetByID(ctx, transactionId, opts...)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Transaction not found", span)
+	}
+
+	if !trx.CanTransitionTo(model.TrxSETTLED) {
+		return nil, response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+	}
+
+	return trx, nil
+}
+
+// settlementItem is the remaining amount of one installment that must be paid on settlement
+type settlementItem struct {
+	installment                       *model.Installment
+	penalty, fee, interest, principal money.Money
+}
+
+// calculateSettlement calculates the early settlement amount of the open installments.
+//
+// Interest of installments that are not due yet at date is waived or charged based on `settlement.interest_policy`.
+// Early termination fee is `settlement.fee_percent` of the remaining principal
+func calculateSettlement(installments []*model.Installment, date time.Time) (*model.SettlementQuote, []settlementItem) {
+	waive := viper.GetString("settlement.interest_policy") != SettlementChargeInterest
+
+	quote := &model.SettlementQuote{}
+	var items []settlementItem
+	for _, inst := range installments {
+		if inst.IsClosed() {
+			continue
+		}
+
+		item := settlementItem{
+			installment: inst,
+			penalty:     inst.PenaltyAmount.Sub(inst.PenaltyPaid),
+			fee:         inst.FeeAmount.Sub(inst.FeePaid),
+			interest:    inst.InterestAmount.Sub(inst.InterestPaid),
+			principal:   inst.PrincipalAmount.Sub(inst.PrincipalPaid),
+		}
+		if waive && inst.DueDate.After(date) {
+			quote.InterestWaived = quote.InterestWaived.Add(item.interest)
+			item.interest = money.Money{}
+		}
+
+		quote.PenaltyAmount = quote.PenaltyAmount.Add(item.penalty)
+		quote.FeeAmount = quote.FeeAmount.Add(item.fee)
+		quote.InterestCharged = quote.InterestCharged.Add(item.interest)
+		quote.RemainingPrincipal = quote.RemainingPrincipal.Add(item.principal)
+		items = append(items, item)
+	}
+
+	quote.TerminationFee = quote.RemainingPrincipal.Percent(viper.GetFloat64("settlement.fee_percent"))
+	quote.TotalAmount = money.Sum(quote.PenaltyAmount, quote.FeeAmount, quote.InterestCharged, quote.RemainingPrincipal, quote.TerminationFee)
+
+	return quote, items
+}
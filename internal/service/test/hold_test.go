@@ -0,0 +1,476 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.portalnesia.com/nullable"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/pricing"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+func TestHoldService_Reserve(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewHoldService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	validate := validator.New()
+	userId := "user-id"
+	tmpReq := dto.HoldRequest{
+		OTR:       money.New(800000),
+		AssetName: "Test asset",
+		Tenor:     3,
+		Reference: "checkout-1",
+	}
+	tmpLimit := model.TenorLimits{
+		ID:            "tenor-limit-id",
+		UserID:        userId,
+		TenorInMonths: 3,
+		LimitAmount:   money.New(1000000),
+	}
+	user := &model.User{ID: userId}
+	version := &model.PricingVersion{
+		ID:             "pricing-version-id",
+		ProductCode:    model.DefaultProduct,
+		Version:        1,
+		InterestMethod: pricing.MethodFlat,
+		AdminFeeType:   pricing.FeePercent,
+		AdminFeeValue:  1,
+		AdminFeeBase:   pricing.FeeBasePrincipalInterest,
+	}
+	expectPricing := func(tenor int) {
+		rate := &model.PricingRate{ID: "rate-id", PricingVersionID: version.ID, Tenor: tenor, InterestRate: 24 / float64(tenor)}
+		mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), model.DefaultProduct, gomock.Any()).Return(version, nil)
+		mock.pricingRepo.EXPECT().GetRate(gomock.Any(), version.ID, tenor).Return(rate, nil)
+	}
+
+	expectTenors := func(tenor int) {
+		mock.pricingRepo.EXPECT().ListActiveTenors(gomock.Any(), model.DefaultProduct, gomock.Any()).Return([]int{tenor}, nil)
+		mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return([]*model.TenorLimits{{UserID: userId, TenorInMonths: tenor}}, nil)
+	}
+
+	cases := []struct {
+		name         string
+		setup        func() (req dto.HoldRequest, err error)
+		notLogin     bool
+		ttl          time.Duration
+		expectedHeld money.Money
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (req dto.HoldRequest, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Invalid request",
+			setup: func() (req dto.HoldRequest, err error) {
+				err = validate.Struct(&req)
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+				return
+			},
+		},
+		{
+			name: "Limit not found",
+			setup: func() (req dto.HoldRequest, err error) {
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				err = response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Available limit is already held",
+			setup: func() (req dto.HoldRequest, err error) {
+				limit := tmpLimit
+				limit.HeldAmount = money.New(500000)
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				err = response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Save hold error",
+			setup: func() (req dto.HoldRequest, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+
+				errs := errors.New("database error save hold")
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				mock.transactionRepo.EXPECT().CreateHold(gomock.Any(), gomock.Any()).Return(errs)
+				return
+			},
+		},
+		{
+			name: "Reserve limit success",
+			setup: func() (req dto.HoldRequest, err error) {
+				limit := tmpLimit
+				limit.HeldAmount = money.New(100000)
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().CreateHold(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, hold *model.LimitHold, opts ...repository.Option) error {
+					assert.Equal(t, model.HoldACTIVE, hold.Status)
+					assert.Equal(t, money.New(824160), hold.Amount)
+					assert.Equal(t, money.New(16000), hold.InterestAmount)
+					assert.Equal(t, money.New(8160), hold.AdminFee)
+					assert.Equal(t, nullable.NewString("checkout-1", true, true), hold.Reference)
+					assert.WithinDuration(t, time.Now().Add(15*time.Minute), hold.ExpiresAt, time.Minute)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, tenorLimit *model.TenorLimits, opts ...repository.Option) error {
+					// only the version is changed by the repository
+					assert.Equal(t, tmpLimit.LimitAmount, tenorLimit.LimitAmount)
+					return nil
+				})
+				return
+			},
+			expectedHeld: money.New(924160),
+		},
+		{
+			name: "Reserve limit with configured ttl",
+			setup: func() (req dto.HoldRequest, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().CreateHold(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, hold *model.LimitHold, opts ...repository.Option) error {
+					assert.WithinDuration(t, time.Now().Add(2*time.Hour), hold.ExpiresAt, time.Minute)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				return
+			},
+			ttl:          2 * time.Hour,
+			expectedHeld: money.New(824160),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			if c.ttl > 0 {
+				viper.Set("hold.ttl", c.ttl)
+				defer viper.Set("hold.ttl", 0)
+			}
+
+			req, expectedErr := c.setup()
+
+			hold, limit, err := svc.Reserve(ctx, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+				assert.NotNil(t, hold)
+				assert.Equal(t, c.expectedHeld, limit.HeldAmount)
+				assert.Equal(t, tmpLimit.LimitAmount, limit.LimitAmount)
+			} else {
+				assert.Equal(t, expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestHoldService_Capture(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewHoldService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	holdId := "hold-id"
+	tmpHold := model.LimitHold{
+		ID:                holdId,
+		UserID:            userId,
+		Tenor:             3,
+		OTR:               money.New(800000),
+		AdminFee:          money.New(8160),
+		InstallmentAmount: money.New(274720),
+		InterestAmount:    money.New(16000),
+		Amount:            money.New(824160),
+		AssetName:         "Test asset",
+		ProductCode:       model.DefaultProduct,
+		PricingVersionID:  nullable.NewString("pricing-version-id", true, true),
+		Status:            model.HoldACTIVE,
+		ExpiresAt:         time.Now().Add(10 * time.Minute),
+	}
+	tmpLimit := model.TenorLimits{
+		ID:            "tenor-limit-id",
+		UserID:        userId,
+		TenorInMonths: 3,
+		LimitAmount:   money.New(1000000),
+		HeldAmount:    money.New(824160),
+	}
+	expectLock := func(hold model.LimitHold, limit model.TenorLimits) {
+		first, locked := hold, hold
+		gomock.InOrder(
+			mock.transactionRepo.EXPECT().GetHold(gomock.Any(), holdId).Return(&first, nil),
+			mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil),
+			mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, hold.Tenor, gomock.Any()).Return(&limit, nil),
+			mock.transactionRepo.EXPECT().GetHold(gomock.Any(), holdId, gomock.Any()).Return(&locked, nil),
+		)
+	}
+
+	cases := []struct {
+		name          string
+		setup         func() error
+		notLogin      bool
+		expectedLimit money.Money
+	}{
+		{
+			name: "User not logged in",
+			setup: func() error {
+				return response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+			},
+			notLogin: true,
+		},
+		{
+			name: "Hold not found",
+			setup: func() error {
+				mock.transactionRepo.EXPECT().GetHold(gomock.Any(), holdId).Return(nil, gorm.ErrRecordNotFound)
+				return response.NotfoundHelper(gorm.ErrRecordNotFound, "Limit hold not found")
+			},
+		},
+		{
+			name: "Hold of another user",
+			setup: func() error {
+				hold := tmpHold
+				hold.UserID = "other-user-id"
+				mock.transactionRepo.EXPECT().GetHold(gomock.Any(), holdId).Return(&hold, nil)
+				return response.NotFound("Limit hold not found")
+			},
+		},
+		{
+			name: "Hold is expired",
+			setup: func() error {
+				hold := tmpHold
+				hold.ExpiresAt = time.Now().Add(-time.Minute)
+				expectLock(hold, tmpLimit)
+				return response.ErrorParameter(response.ErrHoldExpired, response.MsgHoldExpired, fiber.StatusUnprocessableEntity)
+			},
+		},
+		{
+			name: "Hold is already captured",
+			setup: func() error {
+				hold := tmpHold
+				hold.Status = model.HoldCAPTURED
+				expectLock(hold, tmpLimit)
+				return response.ErrorParameter(response.ErrInvalidStatus, response.MsgHoldStatus, fiber.StatusUnprocessableEntity)
+			},
+		},
+		{
+			name: "Limit is lowered after the hold is created",
+			setup: func() error {
+				limit := tmpLimit
+				limit.LimitAmount = money.New(500000)
+				expectLock(tmpHold, limit)
+				return response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+			},
+		},
+		{
+			name: "Save hold error",
+			setup: func() error {
+				expectLock(tmpHold, tmpLimit)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				errs := errors.New("database error save hold")
+				mock.transactionRepo.EXPECT().SaveHold(gomock.Any(), gomock.Any()).Return(errs)
+				return response.ErrorServer(response.MsgInternalServer, errs)
+			},
+		},
+		{
+			name: "Capture hold success",
+			setup: func() error {
+				expectLock(tmpHold, tmpLimit)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, model.TrxPENDING, trx.Status)
+					assert.Equal(t, tmpHold.OTR, trx.OTR)
+					assert.Equal(t, tmpHold.InterestAmount, trx.InterestAmount)
+					assert.Equal(t, tmpHold.AdminFee, trx.AdminFee)
+					assert.Equal(t, tmpHold.PricingVersionID, trx.PricingVersionID)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Len(tmpHold.Tenor)).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().SaveHold(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, hold *model.LimitHold, opts ...repository.Option) error {
+					assert.Equal(t, model.HoldCAPTURED, hold.Status)
+					assert.True(t, hold.TransactionID.Valid)
+					assert.True(t, hold.CapturedAt.Valid)
+					return nil
+				})
+				return nil
+			},
+			expectedLimit: money.New(175840),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			expectedErr := c.setup()
+
+			trx, limit, err := svc.Capture(ctx, holdId)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+				assert.NotNil(t, trx)
+				assert.Equal(t, c.expectedLimit, limit.LimitAmount)
+				assert.True(t, limit.HeldAmount.IsZero())
+			} else {
+				assert.Equal(t, expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestHoldService_Release(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewHoldService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	holdId := "hold-id"
+	tmpHold := model.LimitHold{
+		ID:        holdId,
+		UserID:    userId,
+		Tenor:     3,
+		Amount:    money.New(824160),
+		Status:    model.HoldACTIVE,
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}
+	tmpLimit := model.TenorLimits{
+		ID:            "tenor-limit-id",
+		UserID:        userId,
+		TenorInMonths: 3,
+		LimitAmount:   money.New(1000000),
+		HeldAmount:    money.New(824160),
+	}
+	expectLock := func(hold model.LimitHold) {
+		first, locked, limit := hold, hold, tmpLimit
+		mock.transactionRepo.EXPECT().GetHold(gomock.Any(), holdId).Return(&first, nil)
+		mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+		mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, hold.Tenor, gomock.Any()).Return(&limit, nil)
+		mock.transactionRepo.EXPECT().GetHold(gomock.Any(), holdId, gomock.Any()).Return(&locked, nil)
+	}
+
+	cases := []struct {
+		name  string
+		setup func() error
+	}{
+		{
+			name: "Hold is already released",
+			setup: func() error {
+				hold := tmpHold
+				hold.Status = model.HoldRELEASED
+				expectLock(hold)
+				return response.ErrorParameter(response.ErrInvalidStatus, response.MsgHoldStatus, fiber.StatusUnprocessableEntity)
+			},
+		},
+		{
+			name: "Hold is expired",
+			setup: func() error {
+				hold := tmpHold
+				hold.Status = model.HoldEXPIRED
+				expectLock(hold)
+				return response.ErrorParameter(response.ErrHoldExpired, response.MsgHoldExpired, fiber.StatusUnprocessableEntity)
+			},
+		},
+		{
+			name: "Release hold success",
+			setup: func() error {
+				expectLock(tmpHold)
+				mock.transactionRepo.EXPECT().SaveHold(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, hold *model.LimitHold, opts ...repository.Option) error {
+					assert.Equal(t, model.HoldRELEASED, hold.Status)
+					assert.True(t, hold.ReleasedAt.Valid)
+					return nil
+				})
+				return nil
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "userid", userId)
+
+			expectedErr := c.setup()
+
+			hold, limit, err := svc.Release(ctx, holdId)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, model.HoldRELEASED, hold.Status)
+				assert.True(t, limit.HeldAmount.IsZero())
+				assert.Equal(t, tmpLimit.LimitAmount, limit.LimitAmount)
+			} else {
+				assert.Equal(t, expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/spf13/viper"
+	"go.portalnesia.com/utils"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/pricing"
+	"xyz/pkg/response"
+)
+
+// benchLatency is the simulated round trip of one database statement
+const benchLatency = 100 * time.Microsecond
+
+// benchDatabase opens gorm on sqlmock, so the benchmark runs the SQL of the real repositories
+func benchDatabase(b *testing.B) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return db, mock
+}
+
+// expectCreate expects the statements of one TransactionService.Create, in order.
+// It returns the number of statements
+func expectCreate(mock sqlmock.Sqlmock, userId string, tenor int, strategy string) int {
+	statements := 0
+	query := func(sql string, rows *sqlmock.Rows) {
+		mock.ExpectQuery(sql).WillDelayFor(benchLatency).WillReturnRows(rows)
+		statements++
+	}
+	exec := func(sql string) {
+		mock.ExpectExec(sql).WillDelayFor(benchLatency).WillReturnResult(sqlmock.NewResult(0, 1))
+		statements++
+	}
+	begin := func() {
+		mock.ExpectBegin()
+		statements++
+	}
+	commit := func() {
+		mock.ExpectCommit()
+		statements++
+	}
+	amount := money.New(1_000_000_000_000).String()
+
+	// check tenor, without lock
+	query("SELECT `tenor` FROM `pricing_rates`", sqlmock.NewRows([]string{"tenor"}).AddRow(tenor))
+	query("FROM `user_tenor_limits`", sqlmock.NewRows([]string{"id", "user_id", "tenor_in_months", "limit_amount"}).AddRow(userId, userId, tenor, amount))
+
+	lock := ""
+	if strategy == service.LimitPessimistic {
+		lock = ".*FOR UPDATE"
+	}
+
+	begin()
+	query("SELECT \\* FROM `users`", sqlmock.NewRows([]string{"id"}).AddRow(userId))
+	query("SELECT \\* FROM `pricing_versions`", sqlmock.NewRows([]string{"id", "product_code", "version", "interest_method", "admin_fee_type", "admin_fee_value", "admin_fee_base"}).
+		AddRow("pricing-version-id", model.DefaultProduct, 1, pricing.MethodFlat, pricing.FeePercent, 1, pricing.FeeBasePrincipalInterest))
+	query("SELECT \\* FROM `pricing_rates`", sqlmock.NewRows([]string{"id", "pricing_version_id", "tenor", "interest_rate"}).AddRow("rate-id", "pricing-version-id", tenor, 8))
+	query("FROM `user_credit_lines`"+lock, sqlmock.NewRows([]string{"id", "user_id", "limit_amount", "version"}).AddRow(userId, userId, amount, 1))
+	query("FROM `user_tenor_limits`"+lock, sqlmock.NewRows([]string{"id", "user_id", "tenor_in_months", "limit_amount", "version"}).AddRow(userId, userId, tenor, amount, 1))
+	exec("UPDATE `user_credit_lines`")
+	exec("UPDATE `user_tenor_limits`")
+	exec("INSERT INTO `limit_ledger`")
+	// contract number after the limit debit, so the sequence row is locked only at the end
+	exec("INSERT INTO `contract_sequences`")
+	query("SELECT \\* FROM `contract_sequences`", sqlmock.NewRows([]string{"sequence_key", "last_number"}).AddRow("key", 1))
+	exec("INSERT INTO `transactions`")
+	exec("INSERT INTO `transaction_status_history`")
+	exec("INSERT INTO `installments`")
+	commit()
+
+	return statements
+}
+
+// BenchmarkTransactionService_Create runs TransactionService.Create of both limit strategies with the real repositories on sqlmock,
+// every statement takes benchLatency. The expectations are in order, so it also checks that the contract number
+// is taken after the limit debit, in the same database transaction.
+//
+// sqlmock does not lock rows, so the waiting for a row lock is not part of the result
+func BenchmarkTransactionService_Create(b *testing.B) {
+	req := dto.TransactionRequest{
+		OTR:       money.New(800000),
+		AssetName: "Test asset",
+		Tenor:     3,
+	}
+	userId := "user-id"
+
+	for _, strategy := range []string{service.LimitPessimistic, service.LimitOptimistic} {
+		b.Run(strategy, func(b *testing.B) {
+			viper.Set("limit.concurrency", strategy)
+			defer viper.Set("limit.concurrency", "")
+
+			db, mock := benchDatabase(b)
+			svc := service.NewTransactionService(repository.NewUserRepository(db), repository.NewTransactionRepository(db), repository.NewPricingRepository(db), repository.NewMerchantRepository(db))
+			ctx := context.WithValue(context.Background(), "userid", userId)
+
+			statements := 0
+			for i := 0; i < b.N; i++ {
+				statements += expectCreate(mock, userId, req.Tenor, strategy)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := svc.Create(ctx, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(statements)/float64(b.N), "statements/op")
+		})
+	}
+}
+
+// conflictRepository counts the optimistic debits that change no row, because another transaction changed the limit first
+type conflictRepository struct {
+	repository.TransactionRepository
+	conflicts atomic.Int64
+}
+
+func (r *conflictRepository) DebitCreditLine(ctx context.Context, creditLine *model.CreditLine, amount money.Money, opts ...repository.Option) (bool, error) {
+	ok, err := r.TransactionRepository.DebitCreditLine(ctx, creditLine, amount, opts...)
+	if err == nil && !ok {
+		r.conflicts.Add(1)
+	}
+	return ok, err
+}
+
+func (r *conflictRepository) DebitTenorLimit(ctx context.Context, limit *model.TenorLimits, amount money.Money, opts ...repository.Option) (bool, error) {
+	ok, err := r.TransactionRepository.DebitTenorLimit(ctx, limit, amount, opts...)
+	if err == nil && !ok {
+		r.conflicts.Add(1)
+	}
+	return ok, err
+}
+
+// seedContention creates a user with a credit line and one tenor limit that never run out,
+// and an active pricing version of a new product, so every run starts its own contract sequence
+func seedContention(b *testing.B, db *gorm.DB, tenor int) (userId, productCode string) {
+	userId = utils.UUID()
+	productCode = "bench-" + userId[:8]
+	amount := money.New(1_000_000_000_000)
+	versionId := utils.UUID()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		user := &model.User{
+			ID:        userId,
+			NIK:       strings.ReplaceAll(userId, "-", "")[:16],
+			FullName:  "Benchmark",
+			LegalName: "Benchmark",
+			BirthDate: "1990-01-01",
+			Salary:    10000000,
+			Role:      "user",
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.CreditLine{ID: utils.UUID(), UserID: userId, LimitAmount: amount, GrantedAmount: amount}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.TenorLimits{ID: utils.UUID(), UserID: userId, TenorInMonths: tenor, LimitAmount: amount, GrantedAmount: amount}).Error; err != nil {
+			return err
+		}
+		version := &model.PricingVersion{
+			ID:             versionId,
+			ProductCode:    productCode,
+			Version:        1,
+			EffectiveFrom:  time.Now().Add(-time.Hour),
+			InterestMethod: pricing.MethodFlat,
+			AdminFeeType:   pricing.FeePercent,
+			AdminFeeValue:  1,
+			AdminFeeBase:   pricing.FeeBasePrincipalInterest,
+		}
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.PricingRate{ID: utils.UUID(), PricingVersionID: versionId, Tenor: tenor, InterestRate: 8}).Error
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return userId, productCode
+}
+
+// BenchmarkTransactionService_CreateParallel runs TransactionService.Create of both limit strategies from many goroutines
+// that debit the same credit line and tenor limit, on the MySQL database of BENCH_MYSQL_DSN
+// (with `parseTime=true`, migrated with goose). It is skipped when BENCH_MYSQL_DSN is empty.
+//
+// Every run seeds a new user and product and does not delete them, so use a database only for the benchmark.
+// It reports the created transactions per second, the optimistic debits that conflicted per transaction
+// and the transactions that failed with LIMIT_CONFLICT after all retries
+func BenchmarkTransactionService_CreateParallel(b *testing.B) {
+	dsn := os.Getenv("BENCH_MYSQL_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_MYSQL_DSN is not set")
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		b.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(100)
+	b.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	for _, strategy := range []string{service.LimitPessimistic, service.LimitOptimistic} {
+		b.Run(strategy, func(b *testing.B) {
+			viper.Set("limit.concurrency", strategy)
+			defer viper.Set("limit.concurrency", "")
+
+			userId, productCode := seedContention(b, db, 3)
+			req := dto.TransactionRequest{
+				OTR:       money.New(800000),
+				AssetName: "Test asset",
+				Product:   productCode,
+				Tenor:     3,
+			}
+			transactionRepository := &conflictRepository{TransactionRepository: repository.NewTransactionRepository(db)}
+			svc := service.NewTransactionService(repository.NewUserRepository(db), transactionRepository, repository.NewPricingRepository(db), repository.NewMerchantRepository(db))
+			ctx := context.WithValue(context.Background(), "userid", userId)
+
+			var failed atomic.Int64
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, _, err := svc.Create(ctx, req)
+					if err == nil {
+						continue
+					}
+					var errRes response.ErrorResponse
+					if errors.As(err, &errRes) && errRes.Code == response.ErrLimitConflict {
+						failed.Add(1)
+						continue
+					}
+					b.Error(err)
+				}
+			})
+			b.StopTimer()
+
+			b.ReportMetric(float64(int64(b.N)-failed.Load())/b.Elapsed().Seconds(), "tx/s")
+			b.ReportMetric(float64(transactionRepository.conflicts.Load())/float64(b.N), "conflicts/op")
+			b.ReportMetric(float64(failed.Load())/float64(b.N), "failed/op")
+		})
+	}
+}
@@ -0,0 +1,367 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.portalnesia.com/nullable"
+	"gorm.io/gorm"
+	"testing"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/response"
+)
+
+func TestLimitService_ListLedger(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewLimitService(mock.userRepo, mock.transactionRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	tenor := 6
+
+	t.Run("Not logged in", func(t *testing.T) {
+		_, _, err := svc.ListLedger(context.Background(), tenor, &dto.LedgerListRequest{})
+		assert.Equal(t, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired), err)
+	})
+
+	t.Run("Tenor limit not found", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "userid", userId)
+		mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor).Return(nil, gorm.ErrRecordNotFound)
+
+		_, _, err := svc.ListLedger(ctx, tenor, &dto.LedgerListRequest{})
+		assert.Equal(t, response.NotfoundHelper(gorm.ErrRecordNotFound, "Tenor limit not found"), err)
+	})
+
+	t.Run("List ledger success", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "userid", userId)
+		entries := []*model.LimitLedger{
+			{ID: "ledger-2", Type: model.LedgerDEBIT, SourceType: model.LedgerSourceTRANSACTION, Amount: money.New(824160), BalanceAfter: money.New(175840)},
+			{ID: "ledger-1", Type: model.LedgerCREDIT, SourceType: model.LedgerSourceOPENING, Amount: money.New(1000000), BalanceAfter: money.New(1000000)},
+		}
+		mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor).Return(&model.TenorLimits{ID: "limit-id", UserID: userId, TenorInMonths: tenor}, nil)
+		mock.transactionRepo.EXPECT().ListLedger(gomock.Any(), userId, gomock.Any()).Return(int64(12), entries, nil)
+
+		req := &dto.LedgerListRequest{Pagination: dto.Pagination{Page: 1, Limit: 10}}
+		res, meta, err := svc.ListLedger(ctx, tenor, req)
+		assert.NoError(t, err)
+		assert.Equal(t, entries, res)
+		assert.Equal(t, int64(12), meta.TotalItems)
+		assert.Equal(t, 2, meta.TotalPages)
+	})
+}
+
+func TestLimitService_Adjust(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewLimitService(mock.userRepo, mock.transactionRepo)
+	defer mock.ctrl.Finish()
+
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	userId := "user-id"
+	tenor := 6
+	tmpLimit := model.TenorLimits{
+		ID:            "limit-id",
+		UserID:        userId,
+		TenorInMonths: tenor,
+		LimitAmount:   money.New(1000000),
+	}
+
+	cases := []struct {
+		name          string
+		setup         func() (req dto.LimitAdjustmentRequest, err error)
+		expectedLimit money.Money
+	}{
+		{
+			name: "Not admin",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(&model.User{ID: adminId, Role: model.RoleUSER}, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Zero amount",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				req = dto.LimitAdjustmentRequest{Reason: "Limit review"}
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				errFields := response.NewErrorFields([2]string{"amount", "Parameter `amount` must not be zero"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Debit more than the tenor limit",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				req = dto.LimitAdjustmentRequest{Amount: money.New(-2000000), Reason: "Limit review"}
+				limit := tmpLimit
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor, gomock.Any()).Return(&limit, nil)
+				errFields := response.NewErrorFields([2]string{"amount", "Debit must not be more than the available tenor limit and credit line"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Debit more than the tenor limit that is not held",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				req = dto.LimitAdjustmentRequest{Amount: money.New(-400000), Reason: "Limit review"}
+				limit := tmpLimit
+				limit.HeldAmount = money.New(700000)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor, gomock.Any()).Return(&limit, nil)
+				errFields := response.NewErrorFields([2]string{"amount", "Debit must not be more than the available tenor limit and credit line"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Debit more than the credit line that is not held",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				req = dto.LimitAdjustmentRequest{Amount: money.New(-400000), Reason: "Limit review"}
+				limit := tmpLimit
+				creditLine := newCreditLine(userId)
+				creditLine.HeldAmount = money.New(99800000)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(creditLine, nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor, gomock.Any()).Return(&limit, nil)
+				errFields := response.NewErrorFields([2]string{"amount", "Debit must not be more than the available tenor limit and credit line"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Debit success with an active hold",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				req = dto.LimitAdjustmentRequest{Amount: money.New(-300000), Reason: "Limit review"}
+				limit := tmpLimit
+				limit.HeldAmount = money.New(700000)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				return
+			},
+			expectedLimit: money.New(700000),
+		},
+		{
+			name: "Debit success",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				req = dto.LimitAdjustmentRequest{Amount: money.New(-400000), Reason: "Limit review"}
+				limit := tmpLimit
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, tenorLimit *model.TenorLimits, opts ...repository.Option) error {
+					assert.Equal(t, money.New(-400000), tenorLimit.GrantedAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry *model.LimitLedger, opts ...repository.Option) error {
+					assert.Equal(t, model.LedgerDEBIT, entry.Type)
+					assert.Equal(t, money.New(400000), entry.Amount)
+					assert.Equal(t, money.New(99600000), entry.CreditLineBalanceAfter)
+					return nil
+				})
+				return
+			},
+			expectedLimit: money.New(600000),
+		},
+		{
+			name: "Credit success",
+			setup: func() (req dto.LimitAdjustmentRequest, err error) {
+				req = dto.LimitAdjustmentRequest{Amount: money.New(500000), Reason: "Limit review"}
+				limit := tmpLimit
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, creditLine *model.CreditLine, opts ...repository.Option) error {
+					assert.Equal(t, money.New(100500000), creditLine.LimitAmount)
+					assert.Equal(t, money.New(500000), creditLine.GrantedAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				return
+			},
+			expectedLimit: money.New(1500000),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "userid", adminId)
+
+			req, expectedErr := c.setup()
+
+			entry, err := svc.Adjust(ctx, userId, tenor, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expectedLimit, entry.BalanceAfter)
+				assert.Equal(t, model.LedgerSourceADJUSTMENT, entry.SourceType)
+				assert.Equal(t, adminId, entry.SourceID)
+				assert.Equal(t, nullable.NewString(req.Reason, true, true), entry.Reason)
+			} else {
+				assert.Equal(t, expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestLimitService_Reconcile(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewLimitService(mock.userRepo, mock.transactionRepo)
+	defer mock.ctrl.Finish()
+
+	// granted 1,000,000 with 300,000 unpaid and 100,000 held, the limit amount without drift is 700,000
+	newReconciliation := func(limitType string, userId string, tenor int, limitAmount money.Money) *model.LimitReconciliation {
+		reconciliation := &model.LimitReconciliation{
+			LimitType:     limitType,
+			LimitID:       fmt.Sprintf("%s-%d", userId, tenor),
+			UserID:        userId,
+			Tenor:         tenor,
+			LimitAmount:   limitAmount,
+			HeldAmount:    money.New(100000),
+			GrantedAmount: money.New(1000000),
+			UnpaidAmount:  money.New(300000),
+		}
+		reconciliation.Calculate()
+		return reconciliation
+	}
+
+	t.Run("Scan error", func(t *testing.T) {
+		errs := errors.New("database error reconcile")
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "", 2).Return(nil, errs)
+
+		_, err := svc.Reconcile(context.Background(), 2, false)
+		assert.Equal(t, response.ErrorServer(response.MsgInternalServer, errs), err)
+	})
+
+	t.Run("Report drift in batches", func(t *testing.T) {
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "", 2).Return([]*model.LimitReconciliation{
+			newReconciliation(model.ReconcileCREDITLINE, "user-1", 0, money.New(700000)),
+			newReconciliation(model.ReconcileTENORLIMIT, "user-1", 3, money.New(700000)),
+			newReconciliation(model.ReconcileCREDITLINE, "user-2", 0, money.New(650000)),
+			newReconciliation(model.ReconcileTENORLIMIT, "user-2", 3, money.New(650000)),
+		}, nil)
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "user-2", 2).Return([]*model.LimitReconciliation{
+			newReconciliation(model.ReconcileCREDITLINE, "user-3", 0, money.New(700000)),
+			newReconciliation(model.ReconcileTENORLIMIT, "user-3", 6, money.New(700000)),
+		}, nil)
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "user-3", 2).Return(nil, nil)
+
+		result, err := svc.Reconcile(context.Background(), 2, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, result.Scanned)
+		assert.Equal(t, 2, result.Mismatches)
+		assert.Equal(t, 0, result.Applied)
+		assert.Len(t, result.Items, 2)
+		assert.Equal(t, model.ReconcileCREDITLINE, result.Items[0].LimitType)
+		assert.Equal(t, model.ReconcileTENORLIMIT, result.Items[1].LimitType)
+		for _, item := range result.Items {
+			assert.Equal(t, "user-2", item.UserID)
+			assert.Equal(t, money.New(400000), item.OutstandingAmount)
+			assert.Equal(t, money.New(550000), item.AvailableAmount)
+			assert.Equal(t, money.New(600000), item.ExpectedAmount)
+			assert.Equal(t, money.New(-50000), item.Drift)
+			assert.False(t, item.Applied)
+		}
+	})
+
+	t.Run("Apply with ledger entries", func(t *testing.T) {
+		creditLine := newReconciliation(model.ReconcileCREDITLINE, "user-1", 0, money.New(650000))
+		drifted := newReconciliation(model.ReconcileTENORLIMIT, "user-1", 3, money.New(650000))
+		clean := newReconciliation(model.ReconcileTENORLIMIT, "user-1", 6, money.New(700000))
+		limit3 := &model.TenorLimits{ID: drifted.LimitID, UserID: "user-1", TenorInMonths: 3, LimitAmount: money.New(650000)}
+		limit6 := &model.TenorLimits{ID: clean.LimitID, UserID: "user-1", TenorInMonths: 6, LimitAmount: money.New(700000)}
+
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "", 2).Return([]*model.LimitReconciliation{creditLine, drifted, clean}, nil)
+		gomock.InOrder(
+			mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), "user-1", gomock.Any()).Return(&model.CreditLine{ID: creditLine.LimitID, UserID: "user-1", LimitAmount: money.New(630000)}, nil),
+			mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), "user-1", 3, gomock.Any()).Return(limit3, nil),
+			mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), "user-1", 6, gomock.Any()).Return(limit6, nil),
+			// the credit line is changed after the scan without the tenor limit, the drift of the credit line is bigger
+			mock.transactionRepo.EXPECT().ListUserLimitReconciliations(gomock.Any(), "user-1").Return([]*model.LimitReconciliation{
+				newReconciliation(model.ReconcileCREDITLINE, "user-1", 0, money.New(630000)),
+				newReconciliation(model.ReconcileTENORLIMIT, "user-1", 3, money.New(650000)),
+				newReconciliation(model.ReconcileTENORLIMIT, "user-1", 6, money.New(700000)),
+			}, nil),
+			mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, creditLine *model.CreditLine, opts ...repository.Option) error {
+				assert.Equal(t, money.New(680000), creditLine.LimitAmount)
+				return nil
+			}),
+			mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), limit3).DoAndReturn(func(ctx context.Context, tenorLimit *model.TenorLimits, opts ...repository.Option) error {
+				assert.Equal(t, money.New(700000), tenorLimit.LimitAmount)
+				return nil
+			}),
+			mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry *model.LimitLedger, opts ...repository.Option) error {
+				assert.Equal(t, model.LedgerCREDIT, entry.Type)
+				assert.Equal(t, model.LedgerSourceRECONCILIATION, entry.SourceType)
+				assert.Equal(t, limit3.ID, entry.SourceID)
+				assert.Equal(t, nullable.NewString(limit3.ID, true, true), entry.TenorLimitID)
+				assert.Equal(t, 3, entry.Tenor)
+				assert.Equal(t, money.New(50000), entry.Amount)
+				assert.Equal(t, money.New(700000), entry.BalanceAfter)
+				assert.Equal(t, money.New(680000), entry.CreditLineBalanceAfter)
+				return nil
+			}),
+			mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, creditLine *model.CreditLine, opts ...repository.Option) error {
+				assert.Equal(t, money.New(700000), creditLine.LimitAmount)
+				return nil
+			}),
+			mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry *model.LimitLedger, opts ...repository.Option) error {
+				assert.Equal(t, model.LedgerCREDIT, entry.Type)
+				assert.Equal(t, model.LedgerSourceRECONCILIATION, entry.SourceType)
+				assert.Equal(t, creditLine.LimitID, entry.SourceID)
+				assert.False(t, entry.TenorLimitID.Valid)
+				assert.Equal(t, 0, entry.Tenor)
+				assert.Equal(t, money.New(20000), entry.Amount)
+				assert.Equal(t, money.New(700000), entry.BalanceAfter)
+				assert.Equal(t, money.New(700000), entry.CreditLineBalanceAfter)
+				return nil
+			}),
+		)
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "user-1", 2).Return(nil, nil)
+
+		result, err := svc.Reconcile(context.Background(), 2, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.Scanned)
+		assert.Equal(t, 2, result.Mismatches)
+		assert.Equal(t, 2, result.Applied)
+		assert.True(t, result.Items[0].Applied)
+		assert.True(t, result.Items[1].Applied)
+	})
+
+	t.Run("Apply error", func(t *testing.T) {
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "", 2).Return([]*model.LimitReconciliation{
+			newReconciliation(model.ReconcileCREDITLINE, "user-1", 0, money.New(650000)),
+			newReconciliation(model.ReconcileTENORLIMIT, "user-1", 3, money.New(650000)),
+		}, nil)
+		mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), "user-1", gomock.Any()).Return(nil, errors.New("database error get credit line"))
+		mock.transactionRepo.EXPECT().ReconcileLimits(gomock.Any(), "user-1", 2).Return(nil, nil)
+
+		result, err := svc.Reconcile(context.Background(), 2, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Mismatches)
+		assert.Equal(t, 0, result.Applied)
+		assert.Equal(t, 2, result.Failed)
+	})
+}
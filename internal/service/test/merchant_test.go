@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.portalnesia.com/nullable"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+func TestMerchantService_Create(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewMerchantService(mock.userRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	validate := validator.New()
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	tmpReq := dto.MerchantRequest{
+		Code:    "DLR-001",
+		Name:    "Dealer Motor Sejahtera",
+		Type:    model.MerchantDEALER,
+		Address: "Jl. Sudirman No. 1",
+	}
+
+	cases := []struct {
+		name  string
+		setup func() (req dto.MerchantRequest, err error)
+	}{
+		{
+			name: "Not admin",
+			setup: func() (req dto.MerchantRequest, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(&model.User{ID: adminId, Role: model.RoleUSER}, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Invalid request",
+			setup: func() (req dto.MerchantRequest, err error) {
+				req = tmpReq
+				req.Type = "marketplace"
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				err = validate.Struct(&req)
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+				return
+			},
+		},
+		{
+			name: "Merchant code is already used",
+			setup: func() (req dto.MerchantRequest, err error) {
+				req = tmpReq
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.merchantRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'DLR-001' for key 'code'"})
+
+				errFields := response.NewErrorFields([2]string{"code", "Merchant code is already used"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Create merchant success",
+			setup: func() (req dto.MerchantRequest, err error) {
+				req = tmpReq
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.merchantRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, merchant *model.Merchant, opts ...repository.Option) error {
+					assert.NotEmpty(t, merchant.ID)
+					assert.True(t, merchant.Active)
+					assert.Equal(t, nullable.NewString(req.Address, true, true), merchant.Address)
+					return nil
+				})
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "userid", adminId)
+
+			req, expectedErr := c.setup()
+
+			merchant, err := svc.Create(ctx, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, req.Code, merchant.Code)
+			} else {
+				assert.Equal(t, expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMerchantService_Delete(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewMerchantService(mock.userRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	ctx := context.WithValue(context.Background(), "userid", adminId)
+
+	t.Run("Merchant not found", func(t *testing.T) {
+		mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+		mock.merchantRepo.EXPECT().GetByID(gomock.Any(), "merchant-id").Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := svc.Delete(ctx, "merchant-id")
+		assert.Equal(t, response.NotfoundHelper(gorm.ErrRecordNotFound, "Merchant not found"), err)
+	})
+
+	t.Run("Merchant is deactivated", func(t *testing.T) {
+		mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+		mock.merchantRepo.EXPECT().GetByID(gomock.Any(), "merchant-id").Return(&model.Merchant{ID: "merchant-id", Active: true}, nil)
+		mock.merchantRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+		merchant, err := svc.Delete(ctx, "merchant-id")
+		assert.NoError(t, err)
+		assert.False(t, merchant.Active)
+	})
+}
+
+func TestMerchantService_Report(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewMerchantService(mock.userRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	ctx := context.WithValue(context.Background(), "userid", adminId)
+
+	t.Run("Date from after date to", func(t *testing.T) {
+		mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+
+		_, err := svc.Report(ctx, dto.MerchantReportRequest{DateFrom: "2025-02-01", DateTo: "2025-01-01"})
+		errFields := response.NewErrorFields([2]string{"date_from", "Parameter `date_from` must be before `date_to`"})
+		assert.Equal(t, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields), err)
+	})
+
+	t.Run("Report error", func(t *testing.T) {
+		mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+		errs := errors.New("database error report")
+		mock.merchantRepo.EXPECT().Report(gomock.Any(), time.Time{}, time.Time{}).Return(nil, errs)
+
+		_, err := svc.Report(ctx, dto.MerchantReportRequest{})
+		assert.Equal(t, response.ErrorServer(response.MsgInternalServer, errs), err)
+	})
+
+	t.Run("Report of date range", func(t *testing.T) {
+		from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+		to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local)
+		report := &model.MerchantReport{MerchantID: "merchant-id", TransactionCount: 4, ApprovedCount: 2, RejectedCount: 1, Volume: money.New(1600000)}
+		report.CalculateApprovalRate()
+
+		mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+		mock.merchantRepo.EXPECT().Report(gomock.Any(), from, to).Return([]*model.MerchantReport{report}, nil)
+
+		reports, err := svc.Report(ctx, dto.MerchantReportRequest{DateFrom: "2025-01-01", DateTo: "2025-01-31"})
+		assert.NoError(t, err)
+		assert.Len(t, reports, 1)
+		assert.Equal(t, 66.67, reports[0].ApprovalRate)
+	})
+}
@@ -0,0 +1,346 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+func TestPaymentService_Create(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewPaymentService(mock.userRepo, mock.transactionRepo, mock.paymentRepo)
+	defer mock.ctrl.Finish()
+
+	validate := validator.New()
+	userId := "user-id"
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	trxId := "transaction-id"
+	tmpTrx := model.Transaction{
+		ID:             trxId,
+		UserID:         userId,
+		Tenor:          2,
+		OTR:            money.New(800000),
+		InterestAmount: money.New(16000),
+		AdminFee:       money.New(8160),
+		Status:         model.TrxAPPROVED,
+	}
+	tmpLimit := model.TenorLimits{
+		ID:            "tenor-limit-id",
+		UserID:        userId,
+		TenorInMonths: 2,
+		LimitAmount:   money.New(0),
+	}
+	newInstallments := func() []*model.Installment {
+		return []*model.Installment{
+			{ID: "inst-1", TransactionID: trxId, Sequence: 1, PrincipalAmount: money.New(400000), InterestAmount: money.New(8000), FeeAmount: money.New(4080), AmountDue: money.New(412080), Status: model.InstallmentUNPAID},
+			{ID: "inst-2", TransactionID: trxId, Sequence: 2, PrincipalAmount: money.New(400000), InterestAmount: money.New(8000), FeeAmount: money.New(4080), AmountDue: money.New(412080), Status: model.InstallmentUNPAID},
+		}
+	}
+
+	type expected struct {
+		applied     money.Money
+		excess      money.Money
+		replenished money.Money
+		allocations int
+	}
+
+	cases := []struct {
+		name     string
+		setup    func() (req dto.PaymentRequest, res *expected, err error)
+		notLogin bool
+		policy   string
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Customer cannot record payment",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				req = dto.PaymentRequest{Amount: money.New(100000)}
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(&model.User{ID: adminId, Role: model.RoleUSER}, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Get user error",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				req = dto.PaymentRequest{Amount: money.New(100000)}
+				errs := errors.New("database error get user")
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(nil, errs)
+				err = response.NotfoundHelper(errs, "User not found")
+				return
+			},
+		},
+		{
+			name: "Invalid request",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				err = validate.Struct(&req)
+				err = response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+				return
+			},
+		},
+		{
+			name: "Transaction not found",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(100000)}
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Transaction not approved",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(100000)}
+				trx := tmpTrx
+				trx.Status = model.TrxPENDING
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				err = response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "No outstanding installment",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(100000)}
+				trx := tmpTrx
+				installments := newInstallments()
+				for _, inst := range installments {
+					inst.AmountPaid = inst.AmountDue
+					inst.Status = model.InstallmentPAID
+				}
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(installments, nil)
+				err = response.ErrorParameter(response.ErrNoOutstanding, response.MsgNoOutstanding, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Save payment error",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(100000)}
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(newInstallments(), nil)
+				mock.paymentRepo.EXPECT().SumReplenished(gomock.Any(), trxId).Return(money.Money{}, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				errs := errors.New("database error save payment")
+				mock.paymentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Partial payment",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(100000)}
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(newInstallments(), nil)
+				mock.paymentRepo.EXPECT().SumReplenished(gomock.Any(), trxId).Return(money.Money{}, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().CreateAllocations(gomock.Any(), gomock.Len(1)).Return(nil)
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(1)).DoAndReturn(func(ctx context.Context, installments []*model.Installment, opts ...repository.Option) error {
+					assert.Equal(t, model.InstallmentPARTIAL, installments[0].Status)
+					assert.Equal(t, money.New(4080), installments[0].FeePaid)
+					assert.Equal(t, money.New(8000), installments[0].InterestPaid)
+					assert.Equal(t, money.New(87920), installments[0].PrincipalPaid)
+					return nil
+				})
+				res = &expected{applied: money.New(100000), replenished: money.New(87920), allocations: 1}
+				return
+			},
+		},
+		{
+			name: "Full payment of the first installment",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(412080)}
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(newInstallments(), nil)
+				mock.paymentRepo.EXPECT().SumReplenished(gomock.Any(), trxId).Return(money.Money{}, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().CreateAllocations(gomock.Any(), gomock.Len(1)).Return(nil)
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(1)).DoAndReturn(func(ctx context.Context, installments []*model.Installment, opts ...repository.Option) error {
+					assert.Equal(t, model.InstallmentPAID, installments[0].Status)
+					return nil
+				})
+				res = &expected{applied: money.New(412080), replenished: money.New(400000), allocations: 1}
+				return
+			},
+		},
+		{
+			name: "Over payment of another user transaction",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(900000), Reference: "BANK-REF-1"}
+				trx := tmpTrx
+				limit := tmpLimit
+				trx.UserID = "another-user-id"
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(newInstallments(), nil)
+				mock.paymentRepo.EXPECT().SumReplenished(gomock.Any(), trxId).Return(money.Money{}, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), trx.UserID, gomock.Any()).Return(newCreditLine(trx.UserID), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), trx.UserID, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().CreateAllocations(gomock.Any(), gomock.Len(2)).Return(nil)
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(2)).DoAndReturn(func(ctx context.Context, installments []*model.Installment, opts ...repository.Option) error {
+					for _, inst := range installments {
+						assert.Equal(t, model.InstallmentPAID, inst.Status)
+					}
+					return nil
+				})
+				// the contract is paid off, so the interest and admin fee go back to the limit too
+				res = &expected{applied: money.New(824160), excess: money.New(75840), replenished: money.New(824160), allocations: 2}
+				return
+			},
+		},
+		{
+			name: "Last installment pays off the contract",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(412080)}
+				trx := tmpTrx
+				limit := tmpLimit
+				installments := newInstallments()
+				installments[0].PrincipalPaid = installments[0].PrincipalAmount
+				installments[0].InterestPaid = installments[0].InterestAmount
+				installments[0].FeePaid = installments[0].FeeAmount
+				installments[0].AmountPaid = installments[0].AmountDue
+				installments[0].Status = model.InstallmentPAID
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(installments, nil)
+				mock.paymentRepo.EXPECT().SumReplenished(gomock.Any(), trxId).Return(money.New(400000), nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, limit *model.TenorLimits, opts ...repository.Option) error {
+					// total amount 824160 minus 400000 that is restored by the first payment
+					assert.Equal(t, money.New(424160), limit.LimitAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().CreateAllocations(gomock.Any(), gomock.Len(1)).Return(nil)
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(1)).Return(nil)
+				res = &expected{applied: money.New(412080), replenished: money.New(424160), allocations: 1}
+				return
+			},
+		},
+		{
+			name: "Replenish principal and interest",
+			setup: func() (req dto.PaymentRequest, res *expected, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				req = dto.PaymentRequest{Amount: money.New(412080)}
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(newInstallments(), nil)
+				mock.paymentRepo.EXPECT().SumReplenished(gomock.Any(), trxId).Return(money.Money{}, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, limit *model.TenorLimits, opts ...repository.Option) error {
+					assert.Equal(t, money.New(408000), limit.LimitAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().CreateAllocations(gomock.Any(), gomock.Len(1)).Return(nil)
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(1)).Return(nil)
+				res = &expected{applied: money.New(412080), replenished: money.New(408000), allocations: 1}
+				return
+			},
+			policy: service.ReplenishPrincipalInterest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", adminId)
+			}
+
+			if c.policy != "" {
+				viper.Set("limit.replenish_policy", c.policy)
+				defer viper.Set("limit.replenish_policy", "")
+			}
+
+			req, expectedRes, expectedErr := c.setup()
+
+			res, err := svc.Create(ctx, trxId, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.NotNil(t, res)
+				assert.Equal(t, req.Amount, res.Amount)
+				assert.Equal(t, expectedRes.applied, res.AppliedAmount)
+				assert.Equal(t, expectedRes.excess, res.ExcessAmount)
+				assert.Equal(t, expectedRes.replenished, res.ReplenishedAmount)
+				assert.Len(t, res.Allocations, expectedRes.allocations)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/response"
+)
+
+func TestPenaltyService_Accrue(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewPenaltyService(mock.transactionRepo)
+	defer mock.ctrl.Finish()
+
+	date := time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)
+	newInstallment := func() *model.Installment {
+		return &model.Installment{
+			ID:            "inst-1",
+			TransactionID: "transaction-id",
+			Sequence:      1,
+			DueDate:       time.Date(2025, 7, 5, 0, 0, 0, 0, time.UTC),
+			AmountDue:     money.New(400000),
+			Status:        model.InstallmentUNPAID,
+		}
+	}
+
+	cases := []struct {
+		name        string
+		penaltyType string
+		setup       func() (res *dto.PenaltyAccrualResult, err error)
+	}{
+		{
+			name:        "List overdue installments error",
+			penaltyType: service.PenaltyPercent,
+			setup: func() (res *dto.PenaltyAccrualResult, err error) {
+				errs := errors.New("database error list overdue")
+				mock.transactionRepo.EXPECT().ListOverdueInstallments(gomock.Any(), date).Return(nil, errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name:        "Percent penalty accrued",
+			penaltyType: service.PenaltyPercent,
+			setup: func() (res *dto.PenaltyAccrualResult, err error) {
+				inst := newInstallment()
+				mock.transactionRepo.EXPECT().ListOverdueInstallments(gomock.Any(), date).Return([]*model.Installment{inst}, nil)
+				// the transaction is locked before the installment, like payment and settlement
+				gomock.InOrder(
+					mock.transactionRepo.EXPECT().GetByID(gomock.Any(), inst.TransactionID, gomock.Any()).Return(&model.Transaction{ID: inst.TransactionID}, nil),
+					mock.transactionRepo.EXPECT().GetInstallment(gomock.Any(), inst.ID, gomock.Any()).Return(inst, nil),
+				)
+				mock.transactionRepo.EXPECT().CreatePenalty(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, penalty *model.InstallmentPenalty, opts ...repository.Option) (bool, error) {
+					assert.Equal(t, 5, penalty.DaysOverdue)
+					assert.Equal(t, money.New(400), penalty.Amount)
+					return true, nil
+				})
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(1)).Return(nil)
+				mock.transactionRepo.EXPECT().AddTransactionPenalty(gomock.Any(), inst.TransactionID, money.New(400)).Return(nil)
+				res = &dto.PenaltyAccrualResult{Date: "2025-07-10", Overdue: 1, Accrued: 1, TotalAmount: money.New(400)}
+				return
+			},
+		},
+		{
+			name:        "Flat penalty is capped",
+			penaltyType: service.PenaltyFlat,
+			setup: func() (res *dto.PenaltyAccrualResult, err error) {
+				inst := newInstallment()
+				inst.PenaltyAmount = money.New(39000)
+				mock.transactionRepo.EXPECT().ListOverdueInstallments(gomock.Any(), date).Return([]*model.Installment{inst}, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), inst.TransactionID, gomock.Any()).Return(&model.Transaction{ID: inst.TransactionID}, nil)
+				mock.transactionRepo.EXPECT().GetInstallment(gomock.Any(), inst.ID, gomock.Any()).Return(inst, nil)
+				mock.transactionRepo.EXPECT().CreatePenalty(gomock.Any(), gomock.Any()).Return(true, nil)
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(1)).Return(nil)
+				mock.transactionRepo.EXPECT().AddTransactionPenalty(gomock.Any(), inst.TransactionID, money.New(1000)).Return(nil)
+				res = &dto.PenaltyAccrualResult{Date: "2025-07-10", Overdue: 1, Accrued: 1, TotalAmount: money.New(1000)}
+				return
+			},
+		},
+		{
+			name:        "Cap already reached",
+			penaltyType: service.PenaltyFlat,
+			setup: func() (res *dto.PenaltyAccrualResult, err error) {
+				inst := newInstallment()
+				inst.PenaltyAmount = money.New(40000)
+				mock.transactionRepo.EXPECT().ListOverdueInstallments(gomock.Any(), date).Return([]*model.Installment{inst}, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), inst.TransactionID, gomock.Any()).Return(&model.Transaction{ID: inst.TransactionID}, nil)
+				mock.transactionRepo.EXPECT().GetInstallment(gomock.Any(), inst.ID, gomock.Any()).Return(inst, nil)
+				res = &dto.PenaltyAccrualResult{Date: "2025-07-10", Overdue: 1, Skipped: 1}
+				return
+			},
+		},
+		{
+			name:        "Already accrued on the same date",
+			penaltyType: service.PenaltyPercent,
+			setup: func() (res *dto.PenaltyAccrualResult, err error) {
+				inst := newInstallment()
+				mock.transactionRepo.EXPECT().ListOverdueInstallments(gomock.Any(), date).Return([]*model.Installment{inst}, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), inst.TransactionID, gomock.Any()).Return(&model.Transaction{ID: inst.TransactionID}, nil)
+				mock.transactionRepo.EXPECT().GetInstallment(gomock.Any(), inst.ID, gomock.Any()).Return(inst, nil)
+				mock.transactionRepo.EXPECT().CreatePenalty(gomock.Any(), gomock.Any()).Return(false, nil)
+				res = &dto.PenaltyAccrualResult{Date: "2025-07-10", Overdue: 1, Skipped: 1}
+				return
+			},
+		},
+		{
+			name:        "Lock transaction error",
+			penaltyType: service.PenaltyPercent,
+			setup: func() (res *dto.PenaltyAccrualResult, err error) {
+				inst := newInstallment()
+				mock.transactionRepo.EXPECT().ListOverdueInstallments(gomock.Any(), date).Return([]*model.Installment{inst}, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), inst.TransactionID, gomock.Any()).Return(nil, errors.New("database error"))
+				res = &dto.PenaltyAccrualResult{Date: "2025-07-10", Overdue: 1, Failed: 1}
+				return
+			},
+		},
+		{
+			name:        "Save installment error",
+			penaltyType: service.PenaltyPercent,
+			setup: func() (res *dto.PenaltyAccrualResult, err error) {
+				inst := newInstallment()
+				mock.transactionRepo.EXPECT().ListOverdueInstallments(gomock.Any(), date).Return([]*model.Installment{inst}, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), inst.TransactionID, gomock.Any()).Return(&model.Transaction{ID: inst.TransactionID}, nil)
+				mock.transactionRepo.EXPECT().GetInstallment(gomock.Any(), inst.ID, gomock.Any()).Return(inst, nil)
+				mock.transactionRepo.EXPECT().CreatePenalty(gomock.Any(), gomock.Any()).Return(true, nil)
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(1)).Return(errors.New("database error"))
+				res = &dto.PenaltyAccrualResult{Date: "2025-07-10", Overdue: 1, Failed: 1}
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			viper.Set("penalty.type", c.penaltyType)
+			viper.Set("penalty.value", 0.1)
+			if c.penaltyType == service.PenaltyFlat {
+				viper.Set("penalty.value", 5000)
+			}
+			viper.Set("penalty.max_percent", 10)
+			defer func() {
+				viper.Set("penalty.type", "")
+				viper.Set("penalty.value", 0)
+				viper.Set("penalty.max_percent", 0)
+			}()
+
+			expectedRes, expectedErr := c.setup()
+
+			res, err := svc.Accrue(context.Background(), date)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.Equal(t, expectedRes, res)
+			}
+		})
+	}
+}
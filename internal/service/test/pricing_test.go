@@ -0,0 +1,300 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/pricing"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+func TestPricingService_CreateVersion(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewPricingService(mock.userRepo, mock.pricingRepo)
+	defer mock.ctrl.Finish()
+
+	validate := validator.New()
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	tmpReq := dto.PricingVersionRequest{
+		ProductCode:    "motorcycle",
+		EffectiveFrom:  time.Now().AddDate(0, 0, 1).Format(time.DateOnly),
+		InterestMethod: pricing.MethodEffective,
+		AdminFeeType:   pricing.FeePercent,
+		AdminFeeValue:  1,
+		AdminFeeMin:    money.New(50000),
+		AdminFeeMax:    money.New(500000),
+		Rates: []dto.PricingRateRequest{
+			{Tenor: 12, InterestRate: 18},
+			{Tenor: 24, InterestRate: 20},
+		},
+	}
+
+	cases := []struct {
+		name            string
+		setup           func() (req dto.PricingVersionRequest, err error)
+		expectedVersion int
+	}{
+		{
+			name: "Not admin",
+			setup: func() (req dto.PricingVersionRequest, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(&model.User{ID: adminId, Role: model.RoleUSER}, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Invalid request",
+			setup: func() (req dto.PricingVersionRequest, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				err = validate.Struct(&req)
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+				return
+			},
+		},
+		{
+			name: "Effective date in the past and duplicate tenor",
+			setup: func() (req dto.PricingVersionRequest, err error) {
+				req = tmpReq
+				req.EffectiveFrom = "2020-01-01"
+				req.Rates = []dto.PricingRateRequest{{Tenor: 12, InterestRate: 18}, {Tenor: 12, InterestRate: 20}}
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				errFields := response.NewErrorFields(
+					[2]string{"effective_from", "Parameter `effective_from` cannot be in the past"},
+					[2]string{"rates", "Duplicate tenor 12"},
+				)
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Save version error",
+			setup: func() (req dto.PricingVersionRequest, err error) {
+				req = tmpReq
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.pricingRepo.EXPECT().GetLatestVersion(gomock.Any(), req.ProductCode, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+
+				errs := errors.New("database error save version")
+				mock.pricingRepo.EXPECT().CreateVersion(gomock.Any(), gomock.Any()).Return(errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "First version of product",
+			setup: func() (req dto.PricingVersionRequest, err error) {
+				req = tmpReq
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.pricingRepo.EXPECT().GetLatestVersion(gomock.Any(), req.ProductCode, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mock.pricingRepo.EXPECT().CreateVersion(gomock.Any(), gomock.Any()).Return(nil)
+				mock.pricingRepo.EXPECT().CreateRates(gomock.Any(), gomock.Len(2)).Return(nil)
+				return
+			},
+			expectedVersion: 1,
+		},
+		{
+			name: "Next version of product",
+			setup: func() (req dto.PricingVersionRequest, err error) {
+				req = tmpReq
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.pricingRepo.EXPECT().GetLatestVersion(gomock.Any(), req.ProductCode, gomock.Any()).Return(&model.PricingVersion{ID: "old-version", Version: 3}, nil)
+				mock.pricingRepo.EXPECT().CreateVersion(gomock.Any(), gomock.Any()).Return(nil)
+				mock.pricingRepo.EXPECT().CreateRates(gomock.Any(), gomock.Len(2)).Return(nil)
+				return
+			},
+			expectedVersion: 4,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "userid", adminId)
+
+			req, expectedErr := c.setup()
+
+			res, err := svc.CreateVersion(ctx, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if c.expectedVersion != 0 {
+				assert.NotNil(t, res)
+				assert.Equal(t, c.expectedVersion, res.Version)
+				assert.Equal(t, pricing.FeeBasePrincipal, res.AdminFeeBase)
+				assert.Len(t, res.Rates, 2)
+				for _, rate := range res.Rates {
+					assert.Equal(t, res.ID, rate.PricingVersionID)
+				}
+			}
+		})
+	}
+}
+
+func TestPricingService_ListVersions(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewPricingService(mock.userRepo, mock.pricingRepo)
+	defer mock.ctrl.Finish()
+
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+
+	cases := []struct {
+		name  string
+		req   dto.PricingListRequest
+		setup func() (res []*model.PricingVersion, err error)
+	}{
+		{
+			name: "Repository error",
+			setup: func() (res []*model.PricingVersion, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				errs := errors.New("repository error")
+				mock.pricingRepo.EXPECT().ListVersions(gomock.Any(), model.DefaultProduct).Return(nil, errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Default product",
+			setup: func() (res []*model.PricingVersion, err error) {
+				res = []*model.PricingVersion{{ID: "version-id", ProductCode: model.DefaultProduct, Version: 1}}
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.pricingRepo.EXPECT().ListVersions(gomock.Any(), model.DefaultProduct).Return(res, nil)
+				return
+			},
+		},
+		{
+			name: "Filter by product",
+			req:  dto.PricingListRequest{ProductCode: "car"},
+			setup: func() (res []*model.PricingVersion, err error) {
+				res = []*model.PricingVersion{{ID: "version-id", ProductCode: "car", Version: 2}}
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.pricingRepo.EXPECT().ListVersions(gomock.Any(), "car").Return(res, nil)
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "userid", adminId)
+
+			expectedRes, expectedErr := c.setup()
+
+			res, err := svc.ListVersions(ctx, c.req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+			assert.Equal(t, expectedRes, res)
+		})
+	}
+}
+
+func TestPricingService_CreateCategory(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewPricingService(mock.userRepo, mock.pricingRepo)
+	defer mock.ctrl.Finish()
+
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	tmpReq := dto.AssetCategoryRequest{
+		Code:                  "motorcycle",
+		Name:                  "Motorcycle",
+		Tenors:                []int{24, 6, 12},
+		MinOTR:                money.New(5000000),
+		MaxOTR:                money.New(80000000),
+		MinDownPaymentPercent: 10,
+	}
+
+	cases := []struct {
+		name  string
+		setup func() (req dto.AssetCategoryRequest, err error)
+	}{
+		{
+			name: "Not admin",
+			setup: func() (req dto.AssetCategoryRequest, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(&model.User{ID: adminId, Role: model.RoleUSER}, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Maximum OTR below minimum and duplicate tenor",
+			setup: func() (req dto.AssetCategoryRequest, err error) {
+				req = tmpReq
+				req.MaxOTR = money.New(1000000)
+				req.Tenors = []int{6, 6}
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				errFields := response.NewErrorFields(
+					[2]string{"max_otr", "Parameter `max_otr` must be greater than `min_otr`"},
+					[2]string{"tenors", "Duplicate tenor 6"},
+				)
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Code is already used",
+			setup: func() (req dto.AssetCategoryRequest, err error) {
+				req = tmpReq
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.pricingRepo.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'motorcycle' for key 'code'"})
+				errFields := response.NewErrorFields([2]string{"code", "Asset category code is already used"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Create category success",
+			setup: func() (req dto.AssetCategoryRequest, err error) {
+				req = tmpReq
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.pricingRepo.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(nil)
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "userid", adminId)
+
+			req, expectedErr := c.setup()
+
+			category, err := svc.CreateCategory(ctx, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, model.Tenors{6, 12, 24}, category.Tenors)
+				assert.True(t, category.Active)
+			} else {
+				assert.Equal(t, expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,447 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.portalnesia.com/nullable"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"xyz/internal/dto"
+	"xyz/internal/model"
+	"xyz/internal/repository"
+	"xyz/internal/service"
+	"xyz/pkg/money"
+	"xyz/pkg/response"
+	"xyz/pkg/validator"
+)
+
+func newSettlementInstallments(trxId string) []*model.Installment {
+	now := time.Now()
+	return []*model.Installment{
+		{ID: "inst-1", TransactionID: trxId, Sequence: 1, DueDate: now.AddDate(0, 0, -10), PrincipalAmount: money.New(400000), InterestAmount: money.New(8000), FeeAmount: money.New(4080), AmountDue: money.New(412080), Status: model.InstallmentUNPAID},
+		{ID: "inst-2", TransactionID: trxId, Sequence: 2, DueDate: now.AddDate(0, 0, 20), PrincipalAmount: money.New(400000), InterestAmount: money.New(8000), FeeAmount: money.New(4080), AmountDue: money.New(412080), Status: model.InstallmentUNPAID},
+	}
+}
+
+func TestSettlementService_Quote(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewSettlementService(mock.userRepo, mock.transactionRepo, mock.paymentRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	trxId := "transaction-id"
+	tmpTrx := model.Transaction{
+		ID:     trxId,
+		UserID: userId,
+		Tenor:  2,
+		Status: model.TrxAPPROVED,
+	}
+
+	cases := []struct {
+		name     string
+		setup    func() (res *model.SettlementQuote, err error)
+		notLogin bool
+		policy   string
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (res *model.SettlementQuote, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Transaction not found",
+			setup: func() (res *model.SettlementQuote, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Transaction owned by another user",
+			setup: func() (res *model.SettlementQuote, err error) {
+				trx := tmpTrx
+				trx.UserID = "another-user-id"
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&trx, nil)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(&model.User{ID: userId, Role: model.RoleUSER}, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Transaction not approved",
+			setup: func() (res *model.SettlementQuote, err error) {
+				trx := tmpTrx
+				trx.Status = model.TrxPENDING
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&trx, nil)
+				err = response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "No outstanding installment",
+			setup: func() (res *model.SettlementQuote, err error) {
+				trx := tmpTrx
+				installments := newSettlementInstallments(trxId)
+				for _, inst := range installments {
+					inst.Status = model.InstallmentPAID
+				}
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId).Return(installments, nil)
+				err = response.ErrorParameter(response.ErrNoOutstanding, response.MsgNoOutstanding, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Save quote error",
+			setup: func() (res *model.SettlementQuote, err error) {
+				trx := tmpTrx
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId).Return(newSettlementInstallments(trxId), nil)
+
+				errs := errors.New("database error save quote")
+				mock.paymentRepo.EXPECT().CreateQuote(gomock.Any(), gomock.Any()).Return(errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Waive unearned interest",
+			setup: func() (res *model.SettlementQuote, err error) {
+				trx := tmpTrx
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId).Return(newSettlementInstallments(trxId), nil)
+				mock.paymentRepo.EXPECT().CreateQuote(gomock.Any(), gomock.Any()).Return(nil)
+				res = &model.SettlementQuote{
+					RemainingPrincipal: money.New(800000),
+					InterestCharged:    money.New(8000),
+					InterestWaived:     money.New(8000),
+					FeeAmount:          money.New(8160),
+					TerminationFee:     money.New(8000),
+					TotalAmount:        money.New(824160),
+				}
+				return
+			},
+			policy: service.SettlementWaiveInterest,
+		},
+		{
+			name: "Charge all interest",
+			setup: func() (res *model.SettlementQuote, err error) {
+				trx := tmpTrx
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId).Return(newSettlementInstallments(trxId), nil)
+				mock.paymentRepo.EXPECT().CreateQuote(gomock.Any(), gomock.Any()).Return(nil)
+				res = &model.SettlementQuote{
+					RemainingPrincipal: money.New(800000),
+					InterestCharged:    money.New(16000),
+					InterestWaived:     money.New(0),
+					FeeAmount:          money.New(8160),
+					TerminationFee:     money.New(8000),
+					TotalAmount:        money.New(832160),
+				}
+				return
+			},
+			policy: service.SettlementChargeInterest,
+		},
+	}
+
+	viper.Set("settlement.fee_percent", 1)
+	defer viper.Set("settlement.fee_percent", 0)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			if c.policy != "" {
+				viper.Set("settlement.interest_policy", c.policy)
+				defer viper.Set("settlement.interest_policy", "")
+			}
+
+			expectedRes, expectedErr := c.setup()
+
+			res, err := svc.Quote(ctx, trxId)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.NotNil(t, res)
+				assert.Equal(t, trxId, res.TransactionID)
+				assert.Equal(t, model.QuoteACTIVE, res.Status)
+				assert.True(t, res.ExpiresAt.After(time.Now()))
+				assert.Equal(t, expectedRes.RemainingPrincipal, res.RemainingPrincipal)
+				assert.Equal(t, expectedRes.InterestCharged, res.InterestCharged)
+				assert.Equal(t, expectedRes.InterestWaived, res.InterestWaived)
+				assert.Equal(t, expectedRes.FeeAmount, res.FeeAmount)
+				assert.Equal(t, expectedRes.TerminationFee, res.TerminationFee)
+				assert.Equal(t, expectedRes.TotalAmount, res.TotalAmount)
+			}
+		})
+	}
+}
+
+func TestSettlementService_Settle(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewSettlementService(mock.userRepo, mock.transactionRepo, mock.paymentRepo)
+	defer mock.ctrl.Finish()
+
+	validate := validator.New()
+	userId := "user-id"
+	adminId := "admin-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	trxId := "transaction-id"
+	quoteId := "quote-id"
+	tmpTrx := model.Transaction{
+		ID:             trxId,
+		UserID:         userId,
+		Tenor:          2,
+		OTR:            money.New(800000),
+		InterestAmount: money.New(16000),
+		AdminFee:       money.New(8160),
+		Status:         model.TrxAPPROVED,
+	}
+	tmpLimit := model.TenorLimits{
+		ID:            "tenor-limit-id",
+		UserID:        userId,
+		TenorInMonths: 2,
+		LimitAmount:   money.New(0),
+	}
+	newQuote := func() *model.SettlementQuote {
+		return &model.SettlementQuote{
+			ID:            quoteId,
+			TransactionID: trxId,
+			UserID:        userId,
+			TotalAmount:   money.New(824160),
+			Status:        model.QuoteACTIVE,
+			ExpiresAt:     time.Now().Add(time.Minute),
+			CreatedAt:     time.Now(),
+		}
+	}
+
+	cases := []struct {
+		name     string
+		setup    func() (req dto.SettleRequest, res *model.Payment, err error)
+		notLogin bool
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Customer cannot settle",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(824160)}
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(&model.User{ID: adminId, Role: model.RoleUSER}, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Get user error",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(824160)}
+				errs := errors.New("database error get user")
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(nil, errs)
+				err = response.NotfoundHelper(errs, "User not found")
+				return
+			},
+		},
+		{
+			name: "Invalid request",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				err = validate.Struct(&req)
+				err = response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+				return
+			},
+		},
+		{
+			name: "Quote not found",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(824160)}
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.paymentRepo.EXPECT().GetQuote(gomock.Any(), quoteId, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Settlement quote not found")
+				return
+			},
+		},
+		{
+			name: "Quote of another transaction",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(824160)}
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				quote := newQuote()
+				quote.TransactionID = "another-transaction-id"
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.paymentRepo.EXPECT().GetQuote(gomock.Any(), quoteId, gomock.Any()).Return(quote, nil)
+				err = response.NotFound("Settlement quote not found")
+				return
+			},
+		},
+		{
+			name: "Quote expired",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(824160)}
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				quote := newQuote()
+				quote.ExpiresAt = time.Now().Add(-time.Minute)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.paymentRepo.EXPECT().GetQuote(gomock.Any(), quoteId, gomock.Any()).Return(quote, nil)
+				err = response.ErrorParameter(response.ErrQuoteExpired, response.MsgQuoteExpired, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Insufficient payment",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(800000)}
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.paymentRepo.EXPECT().GetQuote(gomock.Any(), quoteId, gomock.Any()).Return(newQuote(), nil)
+				err = response.ErrorParameter(response.ErrInsufficientPay, response.MsgInsufficientPay, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Outstanding changed after quote",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(824160)}
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				installments := newSettlementInstallments(trxId)
+				installments[0].PenaltyAmount = money.New(5000)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.paymentRepo.EXPECT().GetQuote(gomock.Any(), quoteId, gomock.Any()).Return(newQuote(), nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(installments, nil)
+				err = response.ErrorParameter(response.ErrQuoteExpired, response.MsgQuoteExpired, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Success",
+			setup: func() (req dto.SettleRequest, res *model.Payment, err error) {
+				req = dto.SettleRequest{QuoteID: quoteId, Amount: money.New(830000), Reference: "BANK-REF-1"}
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.paymentRepo.EXPECT().GetQuote(gomock.Any(), quoteId, gomock.Any()).Return(newQuote(), nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId, gomock.Any()).Return(newSettlementInstallments(trxId), nil)
+				mock.paymentRepo.EXPECT().SumReplenished(gomock.Any(), trxId).Return(money.Money{}, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, limit *model.TenorLimits, opts ...repository.Option) error {
+					// all of the debited total goes back, including the waived interest and the admin fee
+					assert.Equal(t, money.New(824160), limit.LimitAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.paymentRepo.EXPECT().CreateAllocations(gomock.Any(), gomock.Len(3)).DoAndReturn(func(ctx context.Context, allocations []*model.PaymentAllocation, opts ...repository.Option) error {
+					var total money.Money
+					for _, alloc := range allocations {
+						total = total.Add(alloc.Amount)
+					}
+					assert.Equal(t, money.New(824160), total)
+
+					// the early termination fee does not belong to any installment
+					fee := allocations[2]
+					assert.False(t, fee.InstallmentID.Valid)
+					assert.Equal(t, money.New(8000), fee.TerminationFeeAmount)
+					assert.Equal(t, money.New(8000), fee.Amount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().SaveInstallments(gomock.Any(), gomock.Len(2)).DoAndReturn(func(ctx context.Context, installments []*model.Installment, opts ...repository.Option) error {
+					for _, inst := range installments {
+						assert.Equal(t, model.InstallmentSETTLED, inst.Status)
+						assert.Equal(t, inst.PrincipalAmount, inst.PrincipalPaid)
+					}
+					assert.Equal(t, money.New(8000), installments[0].InterestPaid)
+					assert.Equal(t, money.New(0), installments[1].InterestPaid)
+					return nil
+				})
+				mock.paymentRepo.EXPECT().SaveQuote(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, quote *model.SettlementQuote, opts ...repository.Option) error {
+					assert.Equal(t, model.QuoteUSED, quote.Status)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, model.TrxSETTLED, trx.Status)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, history *model.TransactionStatusHistory, opts ...repository.Option) error {
+					assert.Equal(t, nullable.NewString(model.TrxAPPROVED, true, true), history.FromStatus)
+					assert.Equal(t, model.TrxSETTLED, history.ToStatus)
+					assert.Equal(t, adminId, history.ActorID)
+					return nil
+				})
+				res = &model.Payment{Amount: money.New(830000), AppliedAmount: money.New(824160), ExcessAmount: money.New(5840), ReplenishedAmount: money.New(824160)}
+				return
+			},
+		},
+	}
+
+	viper.Set("settlement.fee_percent", 1)
+	defer viper.Set("settlement.fee_percent", 0)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", adminId)
+			}
+
+			req, expectedRes, expectedErr := c.setup()
+
+			res, err := svc.Settle(ctx, trxId, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.NotNil(t, res)
+				assert.Equal(t, expectedRes.Amount, res.Amount)
+				assert.Equal(t, expectedRes.AppliedAmount, res.AppliedAmount)
+				assert.Equal(t, expectedRes.ExcessAmount, res.ExcessAmount)
+				assert.Equal(t, expectedRes.ReplenishedAmount, res.ReplenishedAmount)
+				assert.Len(t, res.Allocations, 3)
+			}
+		})
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"log"
 	"os"
 	"testing"
+	"xyz/internal/model"
 	mock_repository "xyz/mocks/repository"
+	"xyz/pkg/money"
 	"xyz/pkg/otel"
 )
 
@@ -59,6 +61,9 @@ type setupResponse struct {
 
 	userRepo        *mock_repository.MockUserRepository
 	transactionRepo *mock_repository.MockTransactionRepository
+	paymentRepo     *mock_repository.MockPaymentRepository
+	pricingRepo     *mock_repository.MockPricingRepository
+	merchantRepo    *mock_repository.MockMerchantRepository
 }
 
 func setupApp(t *testing.T) *setupResponse {
@@ -67,6 +72,9 @@ func setupApp(t *testing.T) *setupResponse {
 
 	userRepo := mock_repository.NewMockUserRepository(ctrl)
 	transactionRepo := mock_repository.NewMockTransactionRepository(ctrl)
+	paymentRepo := mock_repository.NewMockPaymentRepository(ctrl)
+	pricingRepo := mock_repository.NewMockPricingRepository(ctrl)
+	merchantRepo := mock_repository.NewMockMerchantRepository(ctrl)
 
 	userRepo.EXPECT().StartTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
 		// Jalankan fungsi yang di-pass
@@ -78,10 +86,29 @@ func setupApp(t *testing.T) *setupResponse {
 		err := fn(ctx)
 		return err
 	}).AnyTimes()
+	paymentRepo.EXPECT().StartTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		// Jalankan fungsi yang di-pass
+		err := fn(ctx)
+		return err
+	}).AnyTimes()
+
+	pricingRepo.EXPECT().StartTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		// Jalankan fungsi yang di-pass
+		err := fn(ctx)
+		return err
+	}).AnyTimes()
 
 	return &setupResponse{
 		ctrl:            ctrl,
 		userRepo:        userRepo,
 		transactionRepo: transactionRepo,
+		paymentRepo:     paymentRepo,
+		pricingRepo:     pricingRepo,
+		merchantRepo:    merchantRepo,
 	}
 }
+
+// newCreditLine returns a credit line of the user that covers every tenor limit in the tests
+func newCreditLine(userId string) *model.CreditLine {
+	return &model.CreditLine{ID: "credit-line-id", UserID: userId, LimitAmount: money.New(100000000)}
+}
@@ -10,29 +10,37 @@ package test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"go.portalnesia.com/nullable"
 	"gorm.io/gorm"
 	"testing"
 	"time"
 	"xyz/internal/dto"
 	"xyz/internal/model"
+	"xyz/internal/repository"
 	"xyz/internal/service"
+	"xyz/pkg/contract"
+	"xyz/pkg/money"
+	"xyz/pkg/pricing"
 	"xyz/pkg/response"
 	"xyz/pkg/validator"
 )
 
 func TestTransactionService_Create(t *testing.T) {
 	mock := setupApp(t)
-	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
 	defer mock.ctrl.Finish()
 
 	date := time.Now().Add(-24 * time.Hour)
 	validate := validator.New()
 	userId := "user-id"
 	tmpReq := dto.TransactionRequest{
-		OTR:       800000,
+		OTR:       money.New(800000),
 		AssetName: "Test asset",
 		Tenor:     3,
 	}
@@ -41,7 +49,7 @@ func TestTransactionService_Create(t *testing.T) {
 		ID:            "tenor-limit-id",
 		UserID:        "user-id",
 		TenorInMonths: 3,
-		LimitAmount:   1000000,
+		LimitAmount:   money.New(1000000),
 		CreatedAt:     date,
 		UpdatedAt:     date,
 	}
@@ -50,12 +58,45 @@ func TestTransactionService_Create(t *testing.T) {
 		NIK:      "1234567890",
 		FullName: "John Doe",
 	}
+	version := &model.PricingVersion{
+		ID:             "pricing-version-id",
+		ProductCode:    model.DefaultProduct,
+		Version:        1,
+		InterestMethod: pricing.MethodFlat,
+		AdminFeeType:   pricing.FeePercent,
+		AdminFeeValue:  1,
+		AdminFeeBase:   pricing.FeeBasePrincipalInterest,
+	}
+	motorcycle := model.AssetCategory{
+		ID:                    "category-id",
+		Code:                  "motorcycle",
+		Name:                  "Motorcycle",
+		Tenors:                model.Tenors{6, 12, 18, 24, 36},
+		MinOTR:                money.New(5000000),
+		MaxOTR:                money.New(80000000),
+		MinDownPaymentPercent: 10,
+		Active:                true,
+	}
+	contractKey := fmt.Sprintf("DEFAULT-001-%d-{seq:6}", time.Now().Year())
+	expectPricing := func(tenor int) {
+		// annual flat rate that gives 2% interest for the whole tenor
+		rate := &model.PricingRate{ID: "rate-id", PricingVersionID: version.ID, Tenor: tenor, InterestRate: 24 / float64(tenor)}
+		mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), model.DefaultProduct, gomock.Any()).Return(version, nil)
+		mock.pricingRepo.EXPECT().GetRate(gomock.Any(), version.ID, tenor).Return(rate, nil)
+	}
+
+	expectTenors := func(tenor int) {
+		mock.pricingRepo.EXPECT().ListActiveTenors(gomock.Any(), gomock.Any(), gomock.Any()).Return([]int{tenor}, nil)
+		mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return([]*model.TenorLimits{{UserID: userId, TenorInMonths: tenor}}, nil)
+	}
 
 	cases := []struct {
 		name          string
 		setup         func() (req dto.TransactionRequest, res *model.Transaction, err error)
 		notLogin      bool
-		expectedLimit float64
+		roundingMode  string
+		optimistic    bool
+		expectedLimit money.Money
 	}{
 		{
 			name: "User not logged in",
@@ -80,6 +121,7 @@ func TestTransactionService_Create(t *testing.T) {
 				req = tmpReq
 				errs := gorm.ErrRecordNotFound
 				err = response.NotfoundHelper(errs, "User not found")
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(nil, errs)
 				return
 			},
@@ -90,19 +132,49 @@ func TestTransactionService_Create(t *testing.T) {
 				req = tmpReq
 				errs := errors.New("database error get user")
 				err = response.NotfoundHelper(errs, "user not found")
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(nil, errs)
 				return
 			},
 		},
+		{
+			name: "Pricing version not found",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), model.DefaultProduct, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				err = response.ErrorParameter(response.ErrPricingNotFound, response.MsgPricingNotFound, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Pricing rate of tenor not found",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Product = "motorcycle"
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), req.Product, gomock.Any()).Return(version, nil)
+				mock.pricingRepo.EXPECT().GetRate(gomock.Any(), version.ID, req.Tenor).Return(nil, gorm.ErrRecordNotFound)
+				err = response.ErrorParameter(response.ErrPricingNotFound, response.MsgPricingNotFound, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
 		{
 			name: "Get limit error",
 			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
 				req = tmpReq
 
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
 
 				errs := errors.New("database error get limit")
 				err = response.ErrorServer(response.MsgInternalServer, errs)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
 				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(nil, errs)
 				return
 			},
@@ -112,9 +184,12 @@ func TestTransactionService_Create(t *testing.T) {
 			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
 				req = tmpReq
 
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
 
 				err = response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
 				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
 				return
 			},
@@ -124,13 +199,51 @@ func TestTransactionService_Create(t *testing.T) {
 			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
 				limit := tmpLimit
 				limit.TenorInMonths = 1
-				limit.LimitAmount = 100000
+				limit.LimitAmount = money.New(100000)
 
 				req = tmpReq
-				req.OTR = 100000
+				req.OTR = money.New(100000)
 				req.Tenor = 1
 
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				err = response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Credit limit held by active limit holds",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				limit.HeldAmount = money.New(200000)
+
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				err = response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Credit line is used by other tenors",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				creditLine := newCreditLine(userId)
+				creditLine.LimitAmount = money.New(800000)
+
+				req = tmpReq
+
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(creditLine, nil)
 				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
 				err = response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
 				return
@@ -142,28 +255,143 @@ func TestTransactionService_Create(t *testing.T) {
 				limit := tmpLimit
 				req = tmpReq
 
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
 				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
 
-				errs := errors.New("database error get limit")
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				errs := errors.New("database error save transaction")
 				err = response.ErrorServer(response.MsgInternalServer, errs)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
 				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errs)
 
 				return
 			},
 		},
 		{
-			name: "Save limit error",
+			name: "Generate contract number error",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				errs := errors.New("database error contract sequence")
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(0), errs)
+
+				return
+			},
+		},
+		{
+			name: "Duplicate contract number is retried with the next number",
 			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
 				limit := tmpLimit
 				req = tmpReq
 
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
 				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+
+				duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry for key 'unique_contract'"}
+				gomock.InOrder(
+					mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil),
+					mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(duplicate),
+					mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(2), nil),
+					mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+						assert.Equal(t, fmt.Sprintf("DEFAULT-001-%d-000002", time.Now().Year()), trx.ContractNumber[:len(trx.ContractNumber)-1])
+						return nil
+					}),
+				)
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Len(req.Tenor)).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				return
+			},
+			expectedLimit: money.New(175840),
+		},
+		{
+			name: "Duplicate contract number after all attempts",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry for key 'unique_contract'"}
+				err = response.ErrorServer(response.MsgInternalServer, duplicate)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil).Times(3)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(duplicate).Times(3)
+
+				return
+			},
+		},
+		{
+			name: "Save installments error",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
 				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 
+				errs := errors.New("database error save installments")
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Any()).Return(errs)
+
+				return
+			},
+		},
+		{
+			name: "Save limit error",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+
 				errs := errors.New("database error save limit")
 				err = response.ErrorServer(response.MsgInternalServer, errs)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
 				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(errs)
 
 				return
@@ -175,14 +403,391 @@ func TestTransactionService_Create(t *testing.T) {
 				limit := tmpLimit
 				req = tmpReq
 
+				expectTenors(req.Tenor)
 				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
 				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
-				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, fmt.Sprintf("DEFAULT-001-%d-000001", time.Now().Year()), trx.ContractNumber[:len(trx.ContractNumber)-1])
+					assert.True(t, contract.Valid(trx.ContractNumber))
+					assert.Equal(t, money.New(16000), trx.InterestAmount)
+					assert.Equal(t, money.New(8160), trx.AdminFee)
+					assert.Equal(t, model.DefaultProduct, trx.ProductCode)
+					assert.Equal(t, nullable.NewString(version.ID, true, true), trx.PricingVersionID)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Len(req.Tenor)).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, creditLine *model.CreditLine, opts ...repository.Option) error {
+					// the credit line is debited with the same amount as the tenor limit
+					assert.Equal(t, money.New(99175840), creditLine.LimitAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry *model.LimitLedger, opts ...repository.Option) error {
+					assert.Equal(t, model.LedgerDEBIT, entry.Type)
+					assert.Equal(t, model.LedgerSourceTRANSACTION, entry.SourceType)
+					assert.Equal(t, money.New(824160), entry.Amount)
+					assert.Equal(t, money.New(175840), entry.BalanceAfter)
+					assert.Equal(t, money.New(99175840), entry.CreditLineBalanceAfter)
+					return nil
+				})
+
+				return
+			},
+			expectedLimit: money.New(200000),
+		},
+		{
+			name: "Tenor is not configured for the user",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Tenor = 12
+
+				mock.pricingRepo.EXPECT().ListActiveTenors(gomock.Any(), model.DefaultProduct, gomock.Any()).Return([]int{1, 3, 6, 12, 24}, nil)
+				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return([]*model.TenorLimits{{TenorInMonths: 1}, {TenorInMonths: 3}, {TenorInMonths: 6}}, nil)
+				errFields := response.NewErrorFields([2]string{"tenor", "Tenor must be one of 1, 3, 6 months"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "No tenor of the product is configured for the user",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Product = "car"
+
+				mock.pricingRepo.EXPECT().ListActiveTenors(gomock.Any(), req.Product, gomock.Any()).Return([]int{12, 24, 36}, nil)
+				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return([]*model.TenorLimits{{TenorInMonths: 3}, {TenorInMonths: 6}}, nil)
+				errFields := response.NewErrorFields([2]string{"tenor", "No tenor is available for this product"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Create transaction with 24 months tenor",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				limit.TenorInMonths = 24
+				req = tmpReq
+				req.Tenor = 24
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, 24, trx.Tenor)
+					assert.Equal(t, money.New(34340), trx.InstallmentAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Len(req.Tenor)).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				return
+			},
+		},
+		{
+			name: "Asset category not found",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Category = "boat"
+
+				mock.pricingRepo.EXPECT().GetCategory(gomock.Any(), req.Category).Return(nil, gorm.ErrRecordNotFound)
+				errFields := response.NewErrorFields([2]string{"category", "Asset category is not found or no longer active"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Asset category rules are violated",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Category = "motorcycle"
+
+				mock.pricingRepo.EXPECT().GetCategory(gomock.Any(), req.Category).Return(&motorcycle, nil)
+				errFields := response.NewErrorFields(
+					[2]string{"tenor", "Tenor of Motorcycle must be one of 6, 12, 18, 24, 36 months"},
+					[2]string{"otr", "OTR of Motorcycle must be at least 5000000.00"},
+				)
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Asset category maximum OTR",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Category = "white_goods"
+				category := motorcycle
+				category.Name = "White Goods"
+				category.Tenors = model.Tenors{1, 2, 3, 6}
+				category.MinOTR = money.New(500000)
+				category.MaxOTR = money.New(700000)
+
+				mock.pricingRepo.EXPECT().GetCategory(gomock.Any(), req.Category).Return(&category, nil)
+				errFields := response.NewErrorFields([2]string{"otr", "OTR of White Goods must be at most 700000.00"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Create transaction of asset category",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+				req.Category = "white_goods"
+				category := motorcycle
+				category.Tenors = model.Tenors{1, 2, 3, 6}
+				category.MinOTR = money.New(500000)
+				category.MinDownPaymentPercent = 0
+
+				mock.pricingRepo.EXPECT().GetCategory(gomock.Any(), req.Category).Return(&category, nil)
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, nullable.NewString(req.Category, true, true), trx.AssetCategory)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Len(req.Tenor)).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				return
+			},
+			expectedLimit: money.New(175840),
+		},
+		{
+			name: "Down payment is not less than OTR",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.DownPayment = req.OTR
+
+				errFields := response.NewErrorFields([2]string{"down_payment", "Parameter `down_payment` must be less than `otr`"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Down payment below minimum of asset category",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Category = "motorcycle"
+				req.DownPayment = money.New(50000)
+				category := motorcycle
+				category.Tenors = model.Tenors{3, 6}
+				category.MinOTR = money.New(500000)
+
+				mock.pricingRepo.EXPECT().GetCategory(gomock.Any(), req.Category).Return(&category, nil)
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				errFields := response.NewErrorFields([2]string{"down_payment", "Down payment must be at least 10% of OTR (80000.00)"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Down payment below minimum of product",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.Product = "car"
+				req.DownPayment = money.New(99999)
+				carVersion := *version
+				carVersion.ProductCode = req.Product
+				carVersion.MinDownPaymentPercent = 12.5
+				rate := &model.PricingRate{ID: "rate-id", PricingVersionID: version.ID, Tenor: req.Tenor, InterestRate: 8}
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), req.Product, gomock.Any()).Return(&carVersion, nil)
+				mock.pricingRepo.EXPECT().GetRate(gomock.Any(), version.ID, req.Tenor).Return(rate, nil)
+				errFields := response.NewErrorFields([2]string{"down_payment", "Down payment must be at least 12.5% of OTR (100000.00)"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Create transaction with down payment",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+				req.DownPayment = money.New(200000)
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, req.OTR, trx.OTR)
+					assert.Equal(t, money.New(200000), trx.DownPayment)
+					assert.Equal(t, money.New(600000), trx.FinancedAmount())
+					assert.Equal(t, money.New(12000), trx.InterestAmount)
+					assert.Equal(t, money.New(6120), trx.AdminFee)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, installments []*model.Installment, opts ...repository.Option) error {
+					var principal money.Money
+					for _, inst := range installments {
+						principal = principal.Add(inst.PrincipalAmount)
+					}
+					assert.Equal(t, money.New(600000), principal)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				return
+			},
+			expectedLimit: money.New(381880),
+		},
+		{
+			name: "Merchant is not active",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				req.MerchantID = "merchant-id"
+
+				mock.merchantRepo.EXPECT().GetByID(gomock.Any(), req.MerchantID).Return(&model.Merchant{ID: req.MerchantID, Active: false}, nil)
+				errFields := response.NewErrorFields([2]string{"merchant_id", "Merchant is not found or no longer active"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "Create transaction at merchant",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+				req.MerchantID = "merchant-id"
+
+				mock.merchantRepo.EXPECT().GetByID(gomock.Any(), req.MerchantID).Return(&model.Merchant{ID: req.MerchantID, Type: model.MerchantONLINE, Active: true}, nil)
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, nullable.NewString(req.MerchantID, true, true), trx.MerchantID)
+					assert.Equal(t, nullable.NewString(model.ChannelONLINE, true, true), trx.SalesChannel)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Len(req.Tenor)).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				return
+			},
+			expectedLimit: money.New(175840),
+		},
+		{
+			name: "Create transaction with installment rounding",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+				expectPricing(req.Tenor)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
+				mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, trx *model.Transaction, opts ...repository.Option) error {
+					assert.Equal(t, money.New(275000), trx.InstallmentAmount)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, installments []*model.Installment, opts ...repository.Option) error {
+					assert.Len(t, installments, req.Tenor)
+					assert.Equal(t, money.New(274160), installments[0].AmountDue)
+					assert.Equal(t, money.New(275000), installments[1].AmountDue)
+					assert.Equal(t, money.New(275000), installments[2].AmountDue)
+
+					var principal money.Money
+					for _, inst := range installments {
+						principal = principal.Add(inst.PrincipalAmount)
+					}
+					assert.Equal(t, req.OTR, principal)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
 				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				return
+			},
+			roundingMode:  "up",
+			expectedLimit: money.New(200000),
+		},
+		{
+			name: "Optimistic debit is retried after conflict",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
 
+				expectTenors(req.Tenor)
+				expectAttempt := func(debited bool) {
+					limit := tmpLimit
+					mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil)
+					expectPricing(req.Tenor)
+					// read without lock
+					mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId).Return(newCreditLine(userId), nil)
+					mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor).Return(&limit, nil)
+					// the credit line is changed by a transaction of another tenor in the first attempt
+					mock.transactionRepo.EXPECT().DebitCreditLine(gomock.Any(), gomock.Any(), money.New(824160)).Return(debited, nil)
+					if debited {
+						mock.transactionRepo.EXPECT().DebitTenorLimit(gomock.Any(), gomock.Any(), money.New(824160)).DoAndReturn(func(ctx context.Context, tenorLimit *model.TenorLimits, amount money.Money, opts ...repository.Option) (bool, error) {
+							assert.Equal(t, tmpLimit.LimitAmount, tenorLimit.LimitAmount)
+							return true, nil
+						})
+						mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+						mock.transactionRepo.EXPECT().NextContractSequence(gomock.Any(), contractKey).Return(int64(1), nil)
+						mock.transactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+						mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+						mock.transactionRepo.EXPECT().CreateInstallments(gomock.Any(), gomock.Len(req.Tenor)).Return(nil)
+					}
+				}
+				expectAttempt(false)
+				expectAttempt(true)
+				return
+			},
+			optimistic: true,
+		},
+		{
+			name: "Optimistic debit conflict after all retries",
+			setup: func() (req dto.TransactionRequest, res *model.Transaction, err error) {
+				limit := tmpLimit
+				req = tmpReq
+
+				expectTenors(req.Tenor)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(user, nil).Times(2)
+				mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), model.DefaultProduct, gomock.Any()).Return(version, nil).Times(2)
+				mock.pricingRepo.EXPECT().GetRate(gomock.Any(), version.ID, req.Tenor).Return(&model.PricingRate{ID: "rate-id", PricingVersionID: version.ID, Tenor: req.Tenor, InterestRate: 8}, nil).Times(2)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId).Return(newCreditLine(userId), nil).Times(2)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, req.Tenor).Return(&limit, nil).Times(2)
+				mock.transactionRepo.EXPECT().DebitCreditLine(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+				mock.transactionRepo.EXPECT().DebitTenorLimit(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+				err = response.ErrorParameter(response.ErrLimitConflict, response.MsgLimitConflict, fiber.StatusConflict)
 				return
 			},
-			expectedLimit: 200000,
+			optimistic: true,
 		},
 	}
 
@@ -194,6 +799,26 @@ func TestTransactionService_Create(t *testing.T) {
 				ctx = context.WithValue(ctx, "userid", userId)
 			}
 
+			if c.roundingMode != "" {
+				viper.Set("installment.rounding_unit", 1000)
+				viper.Set("installment.rounding_mode", c.roundingMode)
+				viper.Set("installment.residual", "first")
+				defer func() {
+					viper.Set("installment.rounding_unit", 0)
+					viper.Set("installment.rounding_mode", "")
+					viper.Set("installment.residual", "")
+				}()
+			}
+
+			if c.optimistic {
+				viper.Set("limit.concurrency", service.LimitOptimistic)
+				viper.Set("limit.max_retries", 2)
+				defer func() {
+					viper.Set("limit.concurrency", "")
+					viper.Set("limit.max_retries", 0)
+				}()
+			}
+
 			req, expectedRes, expectedErr := c.setup()
 
 			res, limit, err := svc.Create(ctx, req)
@@ -210,10 +835,841 @@ func TestTransactionService_Create(t *testing.T) {
 
 				assert.Equal(t, expectedRes, res)
 
-				if c.expectedLimit != 0 {
+				if !c.expectedLimit.IsZero() {
 					assert.Equal(t, c.expectedLimit, limit.LimitAmount)
 				}
 			}
 		})
 	}
 }
+
+func TestTransactionService_Simulate(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	tmpReq := dto.SimulationRequest{
+		OTR:       money.New(800000),
+		AssetName: "Test asset",
+	}
+	limits := []*model.TenorLimits{
+		{ID: "limit-1", UserID: userId, TenorInMonths: 1, LimitAmount: money.New(100000)},
+		{ID: "limit-3", UserID: userId, TenorInMonths: 3, LimitAmount: money.New(1000000)},
+		{ID: "limit-6", UserID: userId, TenorInMonths: 6, LimitAmount: money.New(5000000)},
+	}
+	version := &model.PricingVersion{
+		ID:             "pricing-version-id",
+		ProductCode:    model.DefaultProduct,
+		Version:        1,
+		InterestMethod: pricing.MethodFlat,
+		AdminFeeType:   pricing.FeePercent,
+		AdminFeeValue:  1,
+		AdminFeeBase:   pricing.FeeBasePrincipalInterest,
+	}
+	expectPricing := func(tenor int) {
+		// annual flat rate that gives 2% interest for the whole tenor
+		rate := &model.PricingRate{ID: "rate-id", PricingVersionID: version.ID, Tenor: tenor, InterestRate: 24 / float64(tenor)}
+		mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), model.DefaultProduct, gomock.Any()).Return(version, nil)
+		mock.pricingRepo.EXPECT().GetRate(gomock.Any(), version.ID, tenor).Return(rate, nil)
+	}
+
+	cases := []struct {
+		name     string
+		setup    func() (req dto.SimulationRequest, res []*dto.TenorSimulation, err error)
+		notLogin bool
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (req dto.SimulationRequest, res []*dto.TenorSimulation, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Down payment is not less than OTR",
+			setup: func() (req dto.SimulationRequest, res []*dto.TenorSimulation, err error) {
+				req = tmpReq
+				req.DownPayment = req.OTR
+				errFields := response.NewErrorFields([2]string{"down_payment", "Parameter `down_payment` must be less than `otr`"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+		},
+		{
+			name: "List tenor limits error",
+			setup: func() (req dto.SimulationRequest, res []*dto.TenorSimulation, err error) {
+				req = tmpReq
+				errs := errors.New("database error list tenor limits")
+				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return(nil, errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Simulate every tenor limit",
+			setup: func() (req dto.SimulationRequest, res []*dto.TenorSimulation, err error) {
+				req = tmpReq
+				// tenor limits and credit line are read without lock, the credit line caps the 6 months tenor limit
+				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return(limits, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId).Return(&model.CreditLine{ID: "credit-line-id", UserID: userId, LimitAmount: money.New(2000000)}, nil)
+				expectPricing(1)
+				expectPricing(3)
+				mock.pricingRepo.EXPECT().GetActiveVersion(gomock.Any(), model.DefaultProduct, gomock.Any()).Return(version, nil)
+				mock.pricingRepo.EXPECT().GetRate(gomock.Any(), version.ID, 6).Return(nil, gorm.ErrRecordNotFound)
+
+				price := pricing.Result{
+					Principal:         money.New(800000),
+					InterestAmount:    money.New(16000),
+					AdminFee:          money.New(8160),
+					InstallmentAmount: money.New(824160),
+					TotalAmount:       money.New(824160),
+				}
+				res = []*dto.TenorSimulation{
+					{Tenor: 1, Result: price, AvailableAmount: money.New(100000), Eligible: true, Message: response.MsgInsufficientLimit},
+					{Tenor: 3, Result: price, AvailableAmount: money.New(1000000), Eligible: true, WithinLimit: true},
+					{Tenor: 6, AvailableAmount: money.New(2000000), Message: response.MsgPricingNotFound},
+				}
+				res[1].InstallmentAmount = money.New(274720)
+				return
+			},
+		},
+		{
+			name: "Tenor is not allowed by asset category",
+			setup: func() (req dto.SimulationRequest, res []*dto.TenorSimulation, err error) {
+				req = tmpReq
+				req.Category = "electronics"
+				category := &model.AssetCategory{ID: "category-id", Code: "electronics", Name: "Electronics", Tenors: model.Tenors{3}, Active: true}
+				mock.pricingRepo.EXPECT().GetCategory(gomock.Any(), req.Category).Return(category, nil)
+				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return(limits[:2], nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId).Return(newCreditLine(userId), nil)
+				expectPricing(3)
+
+				res = []*dto.TenorSimulation{
+					{Tenor: 1, AvailableAmount: money.New(100000), Message: "Tenor of Electronics must be one of 3 months"},
+					{
+						Tenor: 3,
+						Result: pricing.Result{
+							Principal:         money.New(800000),
+							InterestAmount:    money.New(16000),
+							AdminFee:          money.New(8160),
+							InstallmentAmount: money.New(274720),
+							TotalAmount:       money.New(824160),
+						},
+						AvailableAmount: money.New(1000000),
+						Eligible:        true,
+						WithinLimit:     true,
+					},
+				}
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			req, expectedRes, expectedErr := c.setup()
+
+			res, err := svc.Simulate(ctx, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.Equal(t, expectedRes, res)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Approve(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	adminId := "admin-id"
+	trxId := "transaction-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	user := &model.User{ID: adminId, Role: model.RoleUSER}
+	tmpTrx := model.Transaction{
+		ID:     trxId,
+		UserID: "user-id",
+		OTR:    money.New(800000),
+		Tenor:  3,
+		Status: model.TrxPENDING,
+	}
+
+	cases := []struct {
+		name     string
+		setup    func() (res *model.Transaction, err error)
+		notLogin bool
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (res *model.Transaction, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "User is not admin",
+			setup: func() (res *model.Transaction, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(user, nil)
+				err = response.Authorization(fiber.StatusForbidden, response.ErrForbidden, response.MsgForbidden)
+				return
+			},
+		},
+		{
+			name: "Transaction not found",
+			setup: func() (res *model.Transaction, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Transaction already rejected",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				trx.Status = model.TrxREJECTED
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				err = response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Save transaction error",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+
+				errs := errors.New("database error save transaction")
+				mock.transactionRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Save status history error",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+				errs := errors.New("database error save status history")
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Approve success",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+
+				res = &trx
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", adminId)
+			}
+
+			expectedRes, expectedErr := c.setup()
+
+			res, err := svc.Approve(ctx, trxId)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.NotNil(t, res)
+				assert.Equal(t, model.TrxAPPROVED, res.Status)
+				assert.Equal(t, nullable.NewString(adminId, true, true), res.ReviewedBy)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Reject(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	validate := validator.New()
+	adminId := "admin-id"
+	trxId := "transaction-id"
+	admin := &model.User{ID: adminId, Role: model.RoleADMIN}
+	tmpReq := dto.RejectTransactionRequest{Reason: "Incomplete documents"}
+	tmpTrx := model.Transaction{
+		ID:             trxId,
+		UserID:         "user-id",
+		OTR:            money.New(800000),
+		InterestAmount: money.New(16000),
+		AdminFee:       money.New(8160),
+		Tenor:          3,
+		Status:         model.TrxPENDING,
+	}
+	tmpLimit := model.TenorLimits{
+		ID:            "tenor-limit-id",
+		UserID:        "user-id",
+		TenorInMonths: 3,
+		LimitAmount:   money.New(175840),
+	}
+
+	cases := []struct {
+		name          string
+		setup         func() (req dto.RejectTransactionRequest, res *model.Transaction, err error)
+		expectedLimit money.Money
+	}{
+		{
+			name: "Missing reason",
+			setup: func() (req dto.RejectTransactionRequest, res *model.Transaction, err error) {
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				err = validate.Struct(&req)
+				err = response.ErrorParameter(response.ErrBadRequest, "Invalid request parameter", err)
+				return
+			},
+		},
+		{
+			name: "Transaction already approved",
+			setup: func() (req dto.RejectTransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				trx := tmpTrx
+				trx.Status = model.TrxAPPROVED
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				err = response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Get limit error",
+			setup: func() (req dto.RejectTransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				trx := tmpTrx
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+
+				errs := errors.New("database error get limit")
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), trx.UserID, gomock.Any()).Return(newCreditLine(trx.UserID), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), trx.UserID, trx.Tenor, gomock.Any()).Return(nil, errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Reject success",
+			setup: func() (req dto.RejectTransactionRequest, res *model.Transaction, err error) {
+				req = tmpReq
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), adminId).Return(admin, nil)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), trx.UserID, gomock.Any()).Return(newCreditLine(trx.UserID), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), trx.UserID, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry *model.LimitLedger, opts ...repository.Option) error {
+					assert.Equal(t, model.LedgerCREDIT, entry.Type)
+					assert.Equal(t, model.LedgerSourceREJECTION, entry.SourceType)
+					assert.Equal(t, trxId, entry.SourceID)
+					assert.Equal(t, money.New(1000000), entry.BalanceAfter)
+					return nil
+				})
+				mock.transactionRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, history *model.TransactionStatusHistory, opts ...repository.Option) error {
+					assert.Equal(t, trxId, history.TransactionID)
+					assert.Equal(t, nullable.NewString(model.TrxPENDING, true, true), history.FromStatus)
+					assert.Equal(t, model.TrxREJECTED, history.ToStatus)
+					assert.Equal(t, adminId, history.ActorID)
+					assert.Equal(t, nullable.NewString(req.Reason, true, true), history.Reason)
+					return nil
+				})
+
+				res = &trx
+				return
+			},
+			expectedLimit: money.New(1000000),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "userid", adminId)
+
+			req, expectedRes, expectedErr := c.setup()
+
+			res, limit, err := svc.Reject(ctx, trxId, req)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.NotNil(t, res)
+				assert.Equal(t, model.TrxREJECTED, res.Status)
+				assert.Equal(t, nullable.NewString(req.Reason, true, true), res.RejectionReason)
+				assert.Equal(t, c.expectedLimit, limit.LimitAmount)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Cancel(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	trxId := "transaction-id"
+	tmpTrx := model.Transaction{
+		ID:              trxId,
+		UserID:          userId,
+		OTR:             money.New(800000),
+		InterestAmount:  money.New(16000),
+		AdminFee:        money.New(8160),
+		Tenor:           3,
+		TransactionDate: time.Now().Add(-time.Hour),
+		Status:          model.TrxPENDING,
+	}
+	tmpLimit := model.TenorLimits{
+		ID:            "tenor-limit-id",
+		UserID:        userId,
+		TenorInMonths: 3,
+		LimitAmount:   money.New(175840),
+	}
+
+	cases := []struct {
+		name          string
+		setup         func() (res *model.Transaction, err error)
+		notLogin      bool
+		expectedLimit money.Money
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (res *model.Transaction, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Transaction owned by another user",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				trx.UserID = "another-user-id"
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				err = response.NotFound("Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Transaction already approved",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				trx.Status = model.TrxAPPROVED
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				err = response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Cancel window expired",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				trx.TransactionDate = time.Now().Add(-25 * time.Hour)
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				err = response.ErrorParameter(response.ErrCancelExpired, response.MsgCancelExpired, fiber.StatusUnprocessableEntity)
+				return
+			},
+		},
+		{
+			name: "Save transaction error",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+
+				errs := errors.New("database error save transaction")
+				mock.transactionRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Cancel success",
+			setup: func() (res *model.Transaction, err error) {
+				trx := tmpTrx
+				limit := tmpLimit
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId, gomock.Any()).Return(&trx, nil)
+				mock.transactionRepo.EXPECT().GetCreditLine(gomock.Any(), userId, gomock.Any()).Return(newCreditLine(userId), nil)
+				mock.transactionRepo.EXPECT().GetLimit(gomock.Any(), userId, trx.Tenor, gomock.Any()).Return(&limit, nil)
+				mock.transactionRepo.EXPECT().UpdateCreditLine(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().UpdateTenorLimit(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateLedger(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+				mock.transactionRepo.EXPECT().CreateStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+
+				res = &trx
+				return
+			},
+			expectedLimit: money.New(1000000),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			expectedRes, expectedErr := c.setup()
+
+			res, limit, err := svc.Cancel(ctx, trxId)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.NotNil(t, res)
+				assert.Equal(t, model.TrxCANCELLED, res.Status)
+				assert.Equal(t, c.expectedLimit, limit.LimitAmount)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Get(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	trxId := "transaction-id"
+	contractNumber := "CN-0001"
+	trx := &model.Transaction{ID: trxId, UserID: userId, ContractNumber: contractNumber, Tenor: 2}
+
+	cases := []struct {
+		name       string
+		setup      func() (res *model.Transaction, err error)
+		notLogin   bool
+		byContract bool
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (res *model.Transaction, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Transaction not found",
+			setup: func() (res *model.Transaction, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Transaction owned by another user",
+			setup: func() (res *model.Transaction, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&model.Transaction{ID: trxId, UserID: "another-user-id"}, nil)
+				err = response.NotFound("Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Get by id success",
+			setup: func() (res *model.Transaction, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(trx, nil)
+				res = trx
+				return
+			},
+		},
+		{
+			name: "Contract number not found",
+			setup: func() (res *model.Transaction, err error) {
+				mock.transactionRepo.EXPECT().GetByContractNumber(gomock.Any(), contractNumber).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Transaction not found")
+				return
+			},
+			byContract: true,
+		},
+		{
+			name: "Contract owned by another user",
+			setup: func() (res *model.Transaction, err error) {
+				mock.transactionRepo.EXPECT().GetByContractNumber(gomock.Any(), contractNumber).Return(&model.Transaction{ID: trxId, UserID: "another-user-id"}, nil)
+				err = response.NotFound("Transaction not found")
+				return
+			},
+			byContract: true,
+		},
+		{
+			name: "Get by contract number success",
+			setup: func() (res *model.Transaction, err error) {
+				mock.transactionRepo.EXPECT().GetByContractNumber(gomock.Any(), contractNumber).Return(trx, nil)
+				res = trx
+				return
+			},
+			byContract: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			expectedRes, expectedErr := c.setup()
+
+			var (
+				res *model.Transaction
+				err error
+			)
+			if c.byContract {
+				res, err = svc.GetByContractNumber(ctx, contractNumber)
+			} else {
+				res, err = svc.Get(ctx, trxId)
+			}
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+			assert.Equal(t, expectedRes, res)
+		})
+	}
+}
+
+func TestTransactionService_GetInstallments(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	trxId := "transaction-id"
+	trx := &model.Transaction{ID: trxId, UserID: userId, Tenor: 2}
+
+	cases := []struct {
+		name     string
+		setup    func() (res []*model.Installment, err error)
+		notLogin bool
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (res []*model.Installment, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Transaction not found",
+			setup: func() (res []*model.Installment, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Transaction owned by another user",
+			setup: func() (res []*model.Installment, err error) {
+				other := *trx
+				other.UserID = "another-user-id"
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(&other, nil)
+				err = response.NotFound("Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Repository error",
+			setup: func() (res []*model.Installment, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(trx, nil)
+				errs := errors.New("database error list installments")
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId).Return(nil, errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Successful retrieval",
+			setup: func() (res []*model.Installment, err error) {
+				res = []*model.Installment{
+					{ID: "1", TransactionID: trxId, Sequence: 1, AmountDue: money.New(412080), Status: model.InstallmentUNPAID},
+					{ID: "2", TransactionID: trxId, Sequence: 2, AmountDue: money.New(412080), Status: model.InstallmentUNPAID},
+				}
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(trx, nil)
+				mock.transactionRepo.EXPECT().ListInstallments(gomock.Any(), trxId).Return(res, nil)
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			expectedRes, expectedErr := c.setup()
+
+			res, err := svc.GetInstallments(ctx, trxId)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.Equal(t, expectedRes, res)
+			}
+		})
+	}
+}
+
+func TestTransactionService_GetHistory(t *testing.T) {
+	mock := setupApp(t)
+	svc := service.NewTransactionService(mock.userRepo, mock.transactionRepo, mock.pricingRepo, mock.merchantRepo)
+	defer mock.ctrl.Finish()
+
+	userId := "user-id"
+	trxId := "transaction-id"
+	trx := &model.Transaction{ID: trxId, UserID: "owner-id", Tenor: 2}
+	histories := []*model.TransactionStatusHistory{
+		{ID: "1", TransactionID: trxId, ToStatus: model.TrxPENDING, ActorID: "owner-id"},
+		{ID: "2", TransactionID: trxId, FromStatus: nullable.NewString(model.TrxPENDING, true, true), ToStatus: model.TrxREJECTED, ActorID: "admin-id", Reason: nullable.NewString("Incomplete documents", true, true)},
+	}
+
+	cases := []struct {
+		name     string
+		setup    func() (res []*model.TransactionStatusHistory, err error)
+		notLogin bool
+	}{
+		{
+			name: "User not logged in",
+			setup: func() (res []*model.TransactionStatusHistory, err error) {
+				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+				return
+			},
+			notLogin: true,
+		},
+		{
+			name: "Transaction not found",
+			setup: func() (res []*model.TransactionStatusHistory, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(nil, gorm.ErrRecordNotFound)
+				err = response.NotfoundHelper(gorm.ErrRecordNotFound, "Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Transaction owned by another user",
+			setup: func() (res []*model.TransactionStatusHistory, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(trx, nil)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(&model.User{ID: userId, Role: model.RoleUSER}, nil)
+				err = response.NotFound("Transaction not found")
+				return
+			},
+		},
+		{
+			name: "Repository error",
+			setup: func() (res []*model.TransactionStatusHistory, err error) {
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(trx, nil)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(&model.User{ID: userId, Role: model.RoleADMIN}, nil)
+				errs := errors.New("database error list status history")
+				mock.transactionRepo.EXPECT().ListStatusHistory(gomock.Any(), trxId).Return(nil, errs)
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				return
+			},
+		},
+		{
+			name: "Admin retrieves history of any transaction",
+			setup: func() (res []*model.TransactionStatusHistory, err error) {
+				res = histories
+				mock.transactionRepo.EXPECT().GetByID(gomock.Any(), trxId).Return(trx, nil)
+				mock.userRepo.EXPECT().GetByID(gomock.Any(), userId).Return(&model.User{ID: userId, Role: model.RoleADMIN}, nil)
+				mock.transactionRepo.EXPECT().ListStatusHistory(gomock.Any(), trxId).Return(res, nil)
+				return
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !c.notLogin {
+				ctx = context.WithValue(ctx, "userid", userId)
+			}
+
+			expectedRes, expectedErr := c.setup()
+
+			res, err := svc.GetHistory(ctx, trxId)
+
+			if expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, err != nil)
+				assert.Equal(t, expectedErr, err)
+			}
+
+			if expectedRes != nil {
+				assert.Equal(t, expectedRes, res)
+			}
+		})
+	}
+}
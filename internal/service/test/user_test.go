@@ -21,6 +21,7 @@ import (
 	"xyz/internal/dto"
 	"xyz/internal/model"
 	"xyz/internal/service"
+	"xyz/pkg/money"
 	"xyz/pkg/response"
 	"xyz/pkg/validator"
 )
@@ -384,29 +385,59 @@ func TestUserService_GetTenorLimits(t *testing.T) {
 
 	cases := []struct {
 		name     string
-		setup    func() (res []*model.TenorLimits, err error)
+		setup    func() (res *dto.TenorLimitsResponse, err error)
 		notLogin bool
 	}{
 		{
 			name: "Successful retrieval",
-			setup: func() (res []*model.TenorLimits, err error) {
+			setup: func() (res *dto.TenorLimitsResponse, err error) {
 				tenorLimits := []*model.TenorLimits{
 					{
-						ID: "1", TenorInMonths: 3, LimitAmount: 1000000, CreatedAt: date, UpdatedAt: date,
+						ID: "1", TenorInMonths: 3, LimitAmount: money.New(1000000), CreatedAt: date, UpdatedAt: date,
 						UserID: userId,
 					},
 					{
-						ID: "2", TenorInMonths: 6, LimitAmount: 2000000, CreatedAt: date, UpdatedAt: date,
+						ID: "2", TenorInMonths: 6, LimitAmount: money.New(2000000), CreatedAt: date, UpdatedAt: date,
 						UserID: userId,
 					},
 				}
+				creditLine := &model.CreditLine{ID: "credit-line-id", UserID: userId, LimitAmount: money.New(2000000), HeldAmount: money.New(500000)}
 				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return(tenorLimits, nil)
+				mock.userRepo.EXPECT().GetCreditLine(gomock.Any(), userId).Return(creditLine, nil)
+				res = &dto.TenorLimitsResponse{
+					CreditLine: creditLine,
+					TenorLimits: []*dto.TenorLimitResponse{
+						{ID: "1", TenorInMonths: 3, LimitAmount: money.New(1000000), AvailableAmount: money.New(1000000), CreatedAt: date, UpdatedAt: date},
+						// capped by the available credit line
+						{ID: "2", TenorInMonths: 6, LimitAmount: money.New(2000000), AvailableAmount: money.New(1500000), CreatedAt: date, UpdatedAt: date},
+					},
+				}
+				return
+			},
+		},
+		{
+			name: "User without credit line",
+			setup: func() (res *dto.TenorLimitsResponse, err error) {
+				tenorLimits := []*model.TenorLimits{{ID: "1", TenorInMonths: 3, LimitAmount: money.New(1000000), UserID: userId}}
+				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return(tenorLimits, nil)
+				mock.userRepo.EXPECT().GetCreditLine(gomock.Any(), userId).Return(nil, gorm.ErrRecordNotFound)
+				res = &dto.TenorLimitsResponse{TenorLimits: []*dto.TenorLimitResponse{{ID: "1", TenorInMonths: 3, LimitAmount: money.New(1000000)}}}
+				return
+			},
+		},
+		{
+			name: "Get credit line error",
+			setup: func() (res *dto.TenorLimitsResponse, err error) {
+				errs := errors.New("repository error")
+				err = response.ErrorServer(response.MsgInternalServer, errs)
+				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return(nil, nil)
+				mock.userRepo.EXPECT().GetCreditLine(gomock.Any(), userId).Return(nil, errs)
 				return
 			},
 		},
 		{
 			name: "User not logged in",
-			setup: func() (res []*model.TenorLimits, err error) {
+			setup: func() (res *dto.TenorLimitsResponse, err error) {
 				err = response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
 				return
 			},
@@ -414,7 +445,7 @@ func TestUserService_GetTenorLimits(t *testing.T) {
 		},
 		{
 			name: "Repository error",
-			setup: func() (res []*model.TenorLimits, err error) {
+			setup: func() (res *dto.TenorLimitsResponse, err error) {
 				errs := errors.New("repository error")
 				err = response.ErrorServer(response.MsgInternalServer, errs)
 				mock.userRepo.EXPECT().ListTenorLimits(gomock.Any(), userId).Return(nil, errs)
@@ -456,20 +487,22 @@ func TestUserService_GetTransactions(t *testing.T) {
 	defer mock.ctrl.Finish()
 
 	userId := "user-id"
-	req := dto.Pagination{Page: 1, Limit: 10}
+	validate := validator.New()
+	tmpReq := dto.TransactionListRequest{Pagination: dto.Pagination{Page: 1, Limit: 10}}
 
 	cases := []struct {
 		name     string
 		setup    func() (res []*model.Transaction, meta *response.Meta, err error)
 		err      error
 		notLogin bool
+		req      *dto.TransactionListRequest
 	}{
 		{
 			name: "Successful retrieval",
 			setup: func() (res []*model.Transaction, meta *response.Meta, err error) {
 				res = []*model.Transaction{
-					{ID: "1", UserID: userId, InstallmentAmount: 500000, Status: "success"},
-					{ID: "2", UserID: userId, InstallmentAmount: 1000000, Status: "pending"},
+					{ID: "1", UserID: userId, InstallmentAmount: money.New(500000), Status: "success"},
+					{ID: "2", UserID: userId, InstallmentAmount: money.New(1000000), Status: "pending"},
 				}
 				meta = &response.Meta{TotalItems: 2, CurrentPage: 1, PerPage: 10, TotalPages: 1}
 				mock.userRepo.EXPECT().ListTransactions(gomock.Any(), userId, gomock.Any()).Return(int64(2), res, nil)
@@ -493,6 +526,45 @@ func TestUserService_GetTransactions(t *testing.T) {
 				return
 			},
 		},
+		{
+			name: "Sort field not allowed",
+			setup: func() (res []*model.Transaction, meta *response.Meta, err error) {
+				err = validate.Struct(&dto.TransactionListRequest{Sort: "password"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+				return
+			},
+			req: &dto.TransactionListRequest{Pagination: dto.Pagination{Page: 1, Limit: 10}, Sort: "password"},
+		},
+		{
+			name: "Invalid date range",
+			setup: func() (res []*model.Transaction, meta *response.Meta, err error) {
+				errFields := response.NewErrorFields([2]string{"date_from", "Parameter `date_from` must be before `date_to`"})
+				err = response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+				return
+			},
+			req: &dto.TransactionListRequest{Pagination: dto.Pagination{Page: 1, Limit: 10}, DateFrom: "2025-02-01", DateTo: "2025-01-01"},
+		},
+		{
+			name: "Successful retrieval with filter",
+			setup: func() (res []*model.Transaction, meta *response.Meta, err error) {
+				res = []*model.Transaction{
+					{ID: "2", UserID: userId, InstallmentAmount: money.New(1000000), Status: "pending", Tenor: 3},
+				}
+				meta = &response.Meta{TotalItems: 1, CurrentPage: 1, PerPage: 10, TotalPages: 1}
+				mock.userRepo.EXPECT().ListTransactions(gomock.Any(), userId, gomock.Len(6)).Return(int64(1), res, nil)
+				return
+			},
+			req: &dto.TransactionListRequest{
+				Pagination: dto.Pagination{Page: 1, Limit: 10},
+				Status:     "pending",
+				Tenor:      3,
+				Search:     "motor",
+				DateFrom:   "2025-01-01",
+				DateTo:     "2025-01-31",
+				Sort:       "otr",
+				Order:      "asc",
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -503,6 +575,11 @@ func TestUserService_GetTransactions(t *testing.T) {
 				ctx = context.WithValue(ctx, "userid", userId)
 			}
 
+			req := tmpReq
+			if tc.req != nil {
+				req = *tc.req
+			}
+
 			expectedRes, expectedMeta, expectedErr := tc.setup()
 			res, meta, err := svc.GetTransactions(ctx, &req)
 
@@ -10,32 +10,55 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/dromara/carbon/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"go.portalnesia.com/nullable"
 	"go.portalnesia.com/utils"
 	"gorm.io/gorm"
+	"slices"
 	"time"
 	"xyz/internal/dto"
 	"xyz/internal/model"
 	"xyz/internal/repository"
 	"xyz/pkg/helper"
 	"xyz/pkg/otel"
+	"xyz/pkg/pricing"
 	"xyz/pkg/response"
 	"xyz/pkg/validator"
 )
 
+// defaultCancelWindow is used when `transaction.cancel_window` is not configured
+const defaultCancelWindow = 24 * time.Hour
+
 type TransactionService interface {
 	Create(ctx context.Context, req dto.TransactionRequest) (*model.Transaction, *model.TenorLimits, error)
+	// Simulate calculates the price of the request for every tenor limit of the user, without locking or saving anything
+	Simulate(ctx context.Context, req dto.SimulationRequest) ([]*dto.TenorSimulation, error)
+	Approve(ctx context.Context, id string) (*model.Transaction, error)
+	Reject(ctx context.Context, id string, req dto.RejectTransactionRequest) (*model.Transaction, *model.TenorLimits, error)
+	Cancel(ctx context.Context, id string) (*model.Transaction, *model.TenorLimits, error)
+	Get(ctx context.Context, id string) (*model.Transaction, error)
+	GetByContractNumber(ctx context.Context, contractNumber string) (*model.Transaction, error)
+	GetInstallments(ctx context.Context, id string) ([]*model.Installment, error)
+	// GetHistory returns status history of the transaction that owned by the user, admin can get history of any transaction
+	GetHistory(ctx context.Context, id string) ([]*model.TransactionStatusHistory, error)
 }
 
 type transactionServiceImpl struct {
 	userRepository        repository.UserRepository
 	transactionRepository repository.TransactionRepository
+	pricingRepository     repository.PricingRepository
+	merchantRepository    repository.MerchantRepository
 }
 
-func NewTransactionService(userRepository repository.UserRepository, transactionRepository repository.TransactionRepository) TransactionService {
+func NewTransactionService(userRepository repository.UserRepository, transactionRepository repository.TransactionRepository, pricingRepository repository.PricingRepository, merchantRepository repository.MerchantRepository) TransactionService {
 	return transactionServiceImpl{
 		userRepository:        userRepository,
 		transactionRepository: transactionRepository,
+		pricingRepository:     pricingRepository,
+		merchantRepository:    merchantRepository,
 	}
 }
 
@@ -57,61 +80,374 @@ func (t transactionServiceImpl) Create(ctx context.Context, req dto.TransactionR
 		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
 	}
 
+	merchantID, salesChannel, err := getMerchantOf(ctx, t.merchantRepository, span, req.MerchantID, req.SalesChannel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !req.DownPayment.LessThan(req.OTR) {
+		errFields := response.NewErrorFields([2]string{"down_payment", "Parameter `down_payment` must be less than `otr`"})
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	// check financing rule of the asset category
+	category, err := checkAssetCategory(ctx, t.pricingRepository, span, req.Category, req.Tenor, req.OTR)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// tenor must be offered by the product and configured in the user limits
+	if err = checkTenor(ctx, t.userRepository, t.pricingRepository, span, userid, req.Product, req.Tenor, category); err != nil {
+		return nil, nil, err
+	}
+
 	var (
 		trx   *model.Transaction
 		limit *model.TenorLimits
 	)
-	err := t.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
-		// get user
-		user, err := t.userRepository.GetByID(ctx, userid)
-		if err != nil {
-			return response.NotfoundHelper(err, "User not found", span)
-		}
+	optimistic := optimisticLimit()
+	err = retryLimitConflict(span, func() error {
+		return t.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+			// get user
+			user, err := t.userRepository.GetByID(ctx, userid)
+			if err != nil {
+				return response.NotfoundHelper(err, "User not found", span)
+			}
 
-		date := time.Now()
-		// create transaction
-		trx = &model.Transaction{
-			ID:              utils.UUID(),
-			ContractNumber:  helper.GenerateContractNumber(),
-			UserID:          user.ID,
-			OTR:             req.OTR,
-			AssetName:       req.AssetName,
-			Tenor:           req.Tenor,
-			TransactionDate: date,
-			Status:          model.TrxPENDING,
-			CreatedAt:       date,
-			UpdatedAt:       date,
-		}
-
-		var totalAmount float64
-		trx.InterestAmount, trx.AdminFee, trx.InstallmentAmount, totalAmount = helper.GetTransactionAmount(req.OTR, req.Tenor)
-
-		// get limit
-		limit, err = t.transactionRepository.GetLimit(ctx, user.ID, req.Tenor, repository.WithLockTable())
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
+			date := time.Now()
+			productCode := req.Product
+			if productCode == "" {
+				productCode = model.DefaultProduct
+			}
+
+			// calculate price of the financed amount with the active pricing version
+			price, version, err := calculatePrice(ctx, t.pricingRepository, productCode, req.Tenor, req.OTR.Sub(req.DownPayment), date)
+			if err != nil {
+				return err
+			}
+			if err = checkDownPayment(req.OTR, req.DownPayment, version, category); err != nil {
+				return err
+			}
+
+			// create transaction
+			trx = &model.Transaction{
+				ID:                utils.UUID(),
+				UserID:            user.ID,
+				OTR:               req.OTR,
+				DownPayment:       req.DownPayment,
+				AdminFee:          price.AdminFee,
+				InstallmentAmount: price.InstallmentAmount,
+				InterestAmount:    price.InterestAmount,
+				AssetName:         req.AssetName,
+				AssetCategory:     nullable.NewString(req.Category, true, req.Category != ""),
+				ProductCode:       productCode,
+				PricingVersionID:  nullable.NewString(version.ID, true, true),
+				MerchantID:        merchantID,
+				SalesChannel:      salesChannel,
+				Tenor:             req.Tenor,
+				TransactionDate:   date,
+				Status:            model.TrxPENDING,
+				CreatedAt:         date,
+				UpdatedAt:         date,
+			}
+			totalAmount := price.TotalAmount
+
+			// get credit line and limit, they are locked until the end of the transaction with pessimistic strategy
+			var creditLine *model.CreditLine
+			creditLine, limit, err = getLimits(ctx, t.transactionRepository, user.ID, req.Tenor, limitReadOptions(optimistic)...)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+				}
+				return response.ErrorServer(response.MsgInternalServer, err)
+			}
+
+			// check available limit of both the credit line and the tenor, active limit holds are not available
+			if availableLimit(creditLine, limit).LessThan(totalAmount) {
 				return response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
 			}
+
+			if err = debitLimit(ctx, t.transactionRepository, creditLine, limit, totalAmount, ledgerSource{Type: model.LedgerSourceTRANSACTION, ID: trx.ID}, optimistic); err != nil {
+				return err
+			}
+
+			return saveTransaction(ctx, t.transactionRepository, span, trx)
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return trx, limit, nil
+}
+
+func (t transactionServiceImpl) Simulate(ctx context.Context, req dto.SimulationRequest) ([]*dto.TenorSimulation, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.Simulate")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	if !req.DownPayment.LessThan(req.OTR) {
+		errFields := response.NewErrorFields([2]string{"down_payment", "Parameter `down_payment` must be less than `otr`"})
+		return nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	// check OTR rule of the asset category, the tenor rule is checked per tenor
+	category, err := getAssetCategory(ctx, t.pricingRepository, span, req.Category)
+	if err != nil {
+		return nil, err
+	}
+	if category != nil {
+		if err = validateAssetCategory(category, 0, req.OTR); err != nil {
+			return nil, err
+		}
+	}
+
+	// read without lock, simulation never reserves the limit
+	limits, err := t.userRepository.ListTenorLimits(ctx, userid)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.ListTenorLimits")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+	creditLine, err := t.transactionRepository.GetCreditLine(ctx, userid)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordErrorHelper(err, "repository.GetCreditLine")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	date := time.Now()
+	productCode := req.Product
+	if productCode == "" {
+		productCode = model.DefaultProduct
+	}
+
+	simulations := make([]*dto.TenorSimulation, 0, len(limits))
+	for _, limit := range limits {
+		simulation := &dto.TenorSimulation{Tenor: limit.TenorInMonths}
+		// without credit line nothing is available, the same as transaction creation
+		if creditLine != nil {
+			simulation.AvailableAmount = availableLimit(creditLine, limit)
+		}
+		simulations = append(simulations, simulation)
+
+		if category != nil && !category.Tenors.Contains(limit.TenorInMonths) {
+			simulation.Message = categoryTenorMessage(category)
+			continue
+		}
+
+		price, version, errPrice := calculatePrice(ctx, t.pricingRepository, productCode, limit.TenorInMonths, req.OTR.Sub(req.DownPayment), date)
+		if errPrice != nil {
+			var errRes response.ErrorResponse
+			if errors.As(errPrice, &errRes) && errRes.Code == response.ErrPricingNotFound {
+				simulation.Message = response.MsgPricingNotFound
+				continue
+			}
+			return nil, errPrice
+		}
+		if err = checkDownPayment(req.OTR, req.DownPayment, version, category); err != nil {
+			return nil, err
+		}
+
+		simulation.Result = price
+		simulation.Eligible = true
+		simulation.WithinLimit = !simulation.AvailableAmount.LessThan(price.TotalAmount)
+		if !simulation.WithinLimit {
+			simulation.Message = response.MsgInsufficientLimit
+		}
+	}
+
+	return simulations, nil
+}
+
+// checkTenor checks that the tenor of a new transaction has a rate in the active pricing version of the product
+// and a limit of the user. The asset category, if any, must also allow the tenor.
+//
+// The field error lists the tenors that the user can choose
+func checkTenor(ctx context.Context, userRepository repository.UserRepository, pricingRepository repository.PricingRepository, span *otel.Span, userid, productCode string, tenor int, category *model.AssetCategory) error {
+	if productCode == "" {
+		productCode = model.DefaultProduct
+	}
+
+	productTenors, err := pricingRepository.ListActiveTenors(ctx, productCode, time.Now())
+	if err != nil {
+		span.RecordErrorHelper(err, "PricingRepository.ListActiveTenors")
+		return response.ErrorServer(response.MsgInternalServer, err)
+	}
+	limits, err := userRepository.ListTenorLimits(ctx, userid)
+	if err != nil {
+		span.RecordErrorHelper(err, "UserRepository.ListTenorLimits")
+		return response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	allowed := model.Tenors{}
+	for _, limit := range limits {
+		if !slices.Contains(productTenors, limit.TenorInMonths) {
+			continue
+		}
+		if category != nil && !category.Tenors.Contains(limit.TenorInMonths) {
+			continue
+		}
+		allowed = append(allowed, limit.TenorInMonths)
+	}
+	if allowed.Contains(tenor) {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Tenor must be one of %s months", allowed)
+	if len(allowed) == 0 {
+		msg = "No tenor is available for this product"
+	}
+	errFields := response.NewErrorFields([2]string{"tenor", msg})
+	return response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+}
+
+// saveTransaction saves the new transaction with the next contract number, its status history and its installment schedule.
+// The caller debits the limit before, so the sequence row is locked only at the end of the transaction,
+// and a request that fails on the limit does not take a number.
+//
+// Must be called inside StartTransaction
+func saveTransaction(ctx context.Context, transactionRepository repository.TransactionRepository, span *otel.Span, trx *model.Transaction) error {
+	// save transaction with the next contract number.
+	// Duplicate key only fails the statement in MySQL, so retry with the next number in the same transaction
+	var err error
+	for attempt := 1; ; attempt++ {
+		if trx.ContractNumber, err = nextContractNumber(ctx, transactionRepository, trx.ProductCode, trx.TransactionDate); err != nil {
 			return response.ErrorServer(response.MsgInternalServer, err)
 		}
+		if err = transactionRepository.Create(ctx, trx); err == nil {
+			break
+		}
+		if !response.IsDuplicateError(err) || attempt == maxContractAttempts {
+			return response.ErrorServer(response.MsgInternalServer, err)
+		}
+		span.RecordErrorHelper(err, "TransactionRepository.Create")
+	}
 
-		// check limit
-		if limit.LimitAmount < totalAmount {
-			return response.ErrorParameter(response.ErrInsufficientLimit, response.MsgInsufficientLimit, fiber.StatusUnprocessableEntity)
+	// record the created status
+	if err = recordStatus(ctx, transactionRepository, trx, "", trx.UserID, "", trx.TransactionDate); err != nil {
+		return err
+	}
+
+	// save installment schedule
+	if err = transactionRepository.CreateInstallments(ctx, generateInstallments(trx)); err != nil {
+		return response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return nil
+}
+
+func (t transactionServiceImpl) Approve(ctx context.Context, id string) (*model.Transaction, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.Approve")
+	defer span.End()
+
+	admin, err := getAdmin(ctx, t.userRepository, span)
+	if err != nil {
+		return nil, err
+	}
+
+	var trx *model.Transaction
+	err = t.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		var errTx error
+
+		// get transaction
+		trx, errTx = t.transactionRepository.GetByID(ctx, id, repository.WithLockTable())
+		if errTx != nil {
+			return response.NotfoundHelper(errTx, "Transaction not found", span)
+		}
+
+		// check status
+		if !trx.CanTransitionTo(model.TrxAPPROVED) {
+			return response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
 		}
-		limit.LimitAmount -= totalAmount
+
+		now := time.Now()
+		from := trx.Status
+		trx.Status = model.TrxAPPROVED
+		trx.ReviewedBy = nullable.NewString(admin.ID, true, true)
+		trx.ReviewedAt = nullable.NewTime(now, true, true)
 
 		// save transaction
-		if err = t.transactionRepository.Create(ctx, trx); err != nil {
-			return response.ErrorServer(response.MsgInternalServer, err)
+		if errTx = t.transactionRepository.Save(ctx, trx); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
 		}
 
-		// update limit
-		if err = t.transactionRepository.UpdateTenorLimit(ctx, limit); err != nil {
-			return response.ErrorServer(response.MsgInternalServer, err)
+		return recordStatus(ctx, t.transactionRepository, trx, from, admin.ID, "", now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return trx, nil
+}
+
+func (t transactionServiceImpl) Reject(ctx context.Context, id string, req dto.RejectTransactionRequest) (*model.Transaction, *model.TenorLimits, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.Reject")
+	defer span.End()
+
+	admin, err := getAdmin(ctx, t.userRepository, span)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	validate := validator.New()
+
+	// validate request with validator
+	if err = validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	var (
+		trx   *model.Transaction
+		limit *model.TenorLimits
+	)
+	err = t.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		var errTx error
+
+		// get transaction
+		trx, errTx = t.transactionRepository.GetByID(ctx, id, repository.WithLockTable())
+		if errTx != nil {
+			return response.NotfoundHelper(errTx, "Transaction not found", span)
 		}
 
-		return nil
+		// check status
+		if !trx.CanTransitionTo(model.TrxREJECTED) {
+			return response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+		}
+
+		now := time.Now()
+		from := trx.Status
+		trx.Status = model.TrxREJECTED
+		trx.RejectionReason = nullable.NewString(req.Reason, true, true)
+		trx.ReviewedBy = nullable.NewString(admin.ID, true, true)
+		trx.ReviewedAt = nullable.NewTime(now, true, true)
+
+		// restore deducted limit
+		limit, errTx = restoreLimit(ctx, t.transactionRepository, trx.UserID, trx.Tenor, trx.TotalAmount(), ledgerSource{Type: model.LedgerSourceREJECTION, ID: trx.ID})
+		if errTx != nil {
+			return errTx
+		}
+
+		// save transaction
+		if errTx = t.transactionRepository.Save(ctx, trx); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		return recordStatus(ctx, t.transactionRepository, trx, from, admin.ID, req.Reason, now)
 	})
 	if err != nil {
 		return nil, nil, err
@@ -119,3 +455,227 @@ func (t transactionServiceImpl) Create(ctx context.Context, req dto.TransactionR
 
 	return trx, limit, nil
 }
+
+func (t transactionServiceImpl) Cancel(ctx context.Context, id string) (*model.Transaction, *model.TenorLimits, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.Cancel")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	var (
+		trx   *model.Transaction
+		limit *model.TenorLimits
+	)
+	err := t.transactionRepository.StartTransaction(ctx, func(ctx context.Context) error {
+		var errTx error
+
+		// get transaction
+		trx, errTx = t.getOwnedTransaction(ctx, userid, id, span, repository.WithLockTable())
+		if errTx != nil {
+			return errTx
+		}
+
+		// check status
+		if !trx.CanTransitionTo(model.TrxCANCELLED) {
+			return response.ErrorParameter(response.ErrInvalidStatus, response.MsgInvalidStatus, fiber.StatusUnprocessableEntity)
+		}
+
+		// check cooling-off window
+		now := time.Now()
+		if now.After(trx.TransactionDate.Add(cancelWindow())) {
+			return response.ErrorParameter(response.ErrCancelExpired, response.MsgCancelExpired, fiber.StatusUnprocessableEntity)
+		}
+
+		from := trx.Status
+		trx.Status = model.TrxCANCELLED
+		trx.CancelledAt = nullable.NewTime(now, true, true)
+
+		// refund deducted limit
+		limit, errTx = restoreLimit(ctx, t.transactionRepository, trx.UserID, trx.Tenor, trx.TotalAmount(), ledgerSource{Type: model.LedgerSourceCANCELLATION, ID: trx.ID})
+		if errTx != nil {
+			return errTx
+		}
+
+		// save transaction
+		if errTx = t.transactionRepository.Save(ctx, trx); errTx != nil {
+			return response.ErrorServer(response.MsgInternalServer, errTx)
+		}
+
+		return recordStatus(ctx, t.transactionRepository, trx, from, userid, "", now)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return trx, limit, nil
+}
+
+func (t transactionServiceImpl) Get(ctx context.Context, id string) (*model.Transaction, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.Get")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	return t.getOwnedTransaction(ctx, userid, id, span)
+}
+
+func (t transactionServiceImpl) GetByContractNumber(ctx context.Context, contractNumber string) (*model.Transaction, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.GetByContractNumber")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	trx, err := t.transactionRepository.GetByContractNumber(ctx, contractNumber)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Transaction not found", span)
+	}
+
+	// same response as not found, so the contract number cannot be enumerated
+	if trx.UserID != userid {
+		return nil, response.NotFound("Transaction not found")
+	}
+
+	return trx, nil
+}
+
+func (t transactionServiceImpl) GetInstallments(ctx context.Context, id string) ([]*model.Installment, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.GetInstallments")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	trx, err := t.getOwnedTransaction(ctx, userid, id, span)
+	if err != nil {
+		return nil, err
+	}
+
+	installments, err := t.transactionRepository.ListInstallments(ctx, trx.ID)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.ListInstallments")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return installments, nil
+}
+
+func (t transactionServiceImpl) GetHistory(ctx context.Context, id string) ([]*model.TransactionStatusHistory, error) {
+	var span *otel.Span
+	ctx, span = otel.StartSpan(ctx, "TransactionService.GetHistory")
+	defer span.End()
+
+	userid := helper.GetValueContext(ctx, "userid", "")
+	if userid == "" {
+		return nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
+	}
+
+	trx, err := t.transactionRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Transaction not found", span)
+	}
+
+	// customer support (admin) can read any transaction
+	if trx.UserID != userid {
+		user, errUser := t.userRepository.GetByID(ctx, userid)
+		if errUser != nil || !user.IsAdmin() {
+			return nil, response.NotFound("Transaction not found")
+		}
+	}
+
+	histories, err := t.transactionRepository.ListStatusHistory(ctx, trx.ID)
+	if err != nil {
+		span.RecordErrorHelper(err, "repository.ListStatusHistory")
+		return nil, response.ErrorServer(response.MsgInternalServer, err)
+	}
+
+	return histories, nil
+}
+
+// getOwnedTransaction returns transaction by id only if it belongs to the user.
+//
+// Not found is returned instead of forbidden, so the transaction id cannot be enumerated
+func (t transactionServiceImpl) getOwnedTransaction(ctx context.Context, userid, id string, span *otel.Span, opts ...repository.Option) (*model.Transaction, error) {
+	trx, err := t.transactionRepository.GetByID(ctx, id, opts...)
+	if err != nil {
+		return nil, response.NotfoundHelper(err, "Transaction not found", span)
+	}
+
+	if trx.UserID != userid {
+		return nil, response.NotFound("Transaction not found")
+	}
+
+	return trx, nil
+}
+
+// recordStatus saves the status change of the transaction by the actor to the status history.
+//
+// Must be called inside the same StartTransaction as the status change, so the history is never out of sync
+func recordStatus(ctx context.Context, transactionRepository repository.TransactionRepository, trx *model.Transaction, from, actorId, reason string, date time.Time) error {
+	history := &model.TransactionStatusHistory{
+		ID:            utils.UUID(),
+		TransactionID: trx.ID,
+		FromStatus:    nullable.NewString(from, true, from != ""),
+		ToStatus:      trx.Status,
+		ActorID:       actorId,
+		Reason:        nullable.NewString(reason, true, reason != ""),
+		ChangedAt:     date,
+		CreatedAt:     date,
+	}
+	if err := transactionRepository.CreateStatusHistory(ctx, history); err != nil {
+		return response.ErrorServer(response.MsgInternalServer, err)
+	}
+	return nil
+}
+
+// cancelWindow is the cooling-off period after transaction date where user can cancel the transaction,
+// configured with `transaction.cancel_window`
+func cancelWindow() time.Duration {
+	if window := viper.GetDuration("transaction.cancel_window"); window > 0 {
+		return window
+	}
+	return defaultCancelWindow
+}
+
+// generateInstallments creates monthly installment schedule of the transaction
+func generateInstallments(trx *model.Transaction) []*model.Installment {
+	schedule := pricing.Schedule(pricing.Result{
+		Principal:      trx.FinancedAmount(),
+		InterestAmount: trx.InterestAmount,
+		AdminFee:       trx.AdminFee,
+		TotalAmount:    trx.TotalAmount(),
+	}, trx.Tenor, installmentRounding())
+
+	date := carbon.CreateFromStdTime(trx.TransactionDate)
+	installments := make([]*model.Installment, trx.Tenor)
+	for i := 0; i < trx.Tenor; i++ {
+		installments[i] = &model.Installment{
+			ID:              utils.UUID(),
+			TransactionID:   trx.ID,
+			Sequence:        i + 1,
+			DueDate:         date.AddMonthsNoOverflow(i + 1).StdTime(),
+			PrincipalAmount: schedule[i].Principal,
+			InterestAmount:  schedule[i].Interest,
+			FeeAmount:       schedule[i].Fee,
+			AmountDue:       schedule[i].Amount,
+			Status:          model.InstallmentUNPAID,
+			CreatedAt:       trx.CreatedAt,
+			UpdatedAt:       trx.UpdatedAt,
+		}
+	}
+	return installments
+}
@@ -9,24 +9,36 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"go.portalnesia.com/utils"
+	"gorm.io/gorm"
 	"time"
 	"xyz/internal/dto"
 	"xyz/internal/model"
 	"xyz/internal/repository"
 	"xyz/pkg/helper"
+	"xyz/pkg/money"
 	"xyz/pkg/otel"
 	"xyz/pkg/response"
 	"xyz/pkg/validator"
 )
 
+// transactionSortFields allowed sort parameter of transactions list, mapped to the column
+var transactionSortFields = map[string]string{
+	"transaction_date":   "transaction_date",
+	"created_at":         "created_at",
+	"otr":                "otr",
+	"installment_amount": "installment_amount",
+	"tenor":              "tenor",
+}
+
 type UserService interface {
 	Create(ctx context.Context, user dto.UserRequest) (*model.User, error)
 	GetByID(ctx context.Context, id string) (*model.User, error)
 	Update(ctx context.Context, user dto.UserRequest) (*model.User, error)
-	GetTenorLimits(ctx context.Context) ([]*model.TenorLimits, error)
-	GetTransactions(ctx context.Context, req *dto.Pagination) ([]*model.Transaction, *response.Meta, error)
+	GetTenorLimits(ctx context.Context) (*dto.TenorLimitsResponse, error)
+	GetTransactions(ctx context.Context, req *dto.TransactionListRequest) ([]*model.Transaction, *response.Meta, error)
 }
 
 type userServiceImpl struct {
@@ -183,7 +195,7 @@ func (u userServiceImpl) Update(ctx context.Context, req dto.UserRequest) (*mode
 	return user, nil
 }
 
-func (u userServiceImpl) GetTenorLimits(ctx context.Context) ([]*model.TenorLimits, error) {
+func (u userServiceImpl) GetTenorLimits(ctx context.Context) (*dto.TenorLimitsResponse, error) {
 	var span *otel.Span
 	ctx, span = otel.StartSpan(ctx, "UserService.GetTenorLimits")
 	defer span.End()
@@ -199,10 +211,36 @@ func (u userServiceImpl) GetTenorLimits(ctx context.Context) ([]*model.TenorLimi
 		return nil, response.ErrorServer(response.MsgInternalServer, err)
 	}
 
-	return tenorLimits, nil
+	// user without credit line has no limit that can be used
+	creditLine, err := u.userRepository.GetCreditLine(ctx, userid)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			span.RecordErrorHelper(err, "repository.GetCreditLine")
+			return nil, response.ErrorServer(response.MsgInternalServer, err)
+		}
+		creditLine = nil
+	}
+
+	res := &dto.TenorLimitsResponse{CreditLine: creditLine, TenorLimits: make([]*dto.TenorLimitResponse, 0, len(tenorLimits))}
+	for _, limit := range tenorLimits {
+		var available money.Money
+		if creditLine != nil {
+			available = availableLimit(creditLine, limit)
+		}
+		res.TenorLimits = append(res.TenorLimits, &dto.TenorLimitResponse{
+			ID:              limit.ID,
+			TenorInMonths:   limit.TenorInMonths,
+			LimitAmount:     limit.LimitAmount,
+			HeldAmount:      limit.HeldAmount,
+			AvailableAmount: available,
+			CreatedAt:       limit.CreatedAt,
+			UpdatedAt:       limit.UpdatedAt,
+		})
+	}
+	return res, nil
 }
 
-func (u userServiceImpl) GetTransactions(ctx context.Context, req *dto.Pagination) ([]*model.Transaction, *response.Meta, error) {
+func (u userServiceImpl) GetTransactions(ctx context.Context, req *dto.TransactionListRequest) ([]*model.Transaction, *response.Meta, error) {
 	var span *otel.Span
 	ctx, span = otel.StartSpan(ctx, "UserService.GetTransactions")
 	defer span.End()
@@ -212,7 +250,35 @@ func (u userServiceImpl) GetTransactions(ctx context.Context, req *dto.Paginatio
 		return nil, nil, response.Authorization(fiber.StatusUnauthorized, response.ErrUnauthorized, response.MsgLoginRequired)
 	}
 
-	total, transactions, err := u.userRepository.ListTransactions(ctx, userid, repository.WithPagination(req))
+	validate := validator.New()
+
+	// validate request with validator
+	if err := validate.Struct(req); err != nil {
+		span.RecordErrorHelper(err, "validator")
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, err)
+	}
+
+	// format is already validated
+	var dateFrom, dateTo time.Time
+	if req.DateFrom != "" {
+		dateFrom, _ = time.ParseInLocation(time.DateOnly, req.DateFrom, time.Local)
+	}
+	if req.DateTo != "" {
+		dateTo, _ = time.ParseInLocation(time.DateOnly, req.DateTo, time.Local)
+	}
+	if !dateFrom.IsZero() && !dateTo.IsZero() && dateFrom.After(dateTo) {
+		errFields := response.NewErrorFields([2]string{"date_from", "Parameter `date_from` must be before `date_to`"})
+		return nil, nil, response.ErrorParameter(response.ErrBadRequest, response.MsgInvalidRequest, errFields)
+	}
+
+	total, transactions, err := u.userRepository.ListTransactions(ctx, userid,
+		repository.WithStatus(req.Status),
+		repository.WithTenor(req.Tenor),
+		repository.WithSearch("asset_name", req.Search),
+		repository.WithDateRange("transaction_date", dateFrom, dateTo),
+		repository.WithSort(req.Sort, req.Order, transactionSortFields, "transaction_date"),
+		repository.WithPagination(&req.Pagination),
+	)
 	if err != nil {
 		span.RecordErrorHelper(err, "repository.ListTransactions")
 		return nil, nil, response.ErrorServer(response.MsgInternalServer, err)
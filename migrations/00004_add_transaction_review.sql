@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+	ADD COLUMN role ENUM('user', 'admin') NOT NULL DEFAULT 'user' COMMENT 'Role user (user atau admin)' AFTER salary;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	ADD COLUMN rejection_reason VARCHAR(255) NULL COMMENT 'Alasan penolakan transaksi' AFTER status,
+	ADD COLUMN reviewed_by UUID NULL COMMENT 'Admin yang melakukan approval atau rejection' AFTER rejection_reason,
+	ADD COLUMN reviewed_at TIMESTAMP NULL COMMENT 'Waktu approval atau rejection' AFTER reviewed_by;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions
+	DROP COLUMN reviewed_at,
+	DROP COLUMN reviewed_by,
+	DROP COLUMN rejection_reason;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+	DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS installments (
+	id UUID NOT NULL PRIMARY KEY,
+	transaction_id UUID NOT NULL REFERENCES transactions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	sequence INT NOT NULL COMMENT 'Cicilan ke-n',
+	due_date DATE NOT NULL COMMENT 'Tanggal jatuh tempo',
+	principal_amount DECIMAL(15,2) NOT NULL COMMENT 'Porsi pokok',
+	interest_amount DECIMAL(15,2) NOT NULL COMMENT 'Porsi bunga',
+	fee_amount DECIMAL(15,2) NOT NULL COMMENT 'Porsi admin fee',
+	amount_due DECIMAL(15,2) NOT NULL COMMENT 'Total yang harus dibayar',
+	status ENUM('unpaid', 'partial', 'paid') NOT NULL DEFAULT 'unpaid',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	INDEX idx_installments_due_date (status, due_date),
+	UNIQUE KEY unique_installment_sequence (transaction_id, sequence)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS installments;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE installments
+	ADD COLUMN principal_paid DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Porsi pokok yang sudah dibayar' AFTER amount_due,
+	ADD COLUMN interest_paid DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Porsi bunga yang sudah dibayar' AFTER principal_paid,
+	ADD COLUMN fee_paid DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Porsi admin fee yang sudah dibayar' AFTER interest_paid,
+	ADD COLUMN amount_paid DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Total yang sudah dibayar' AFTER fee_paid,
+	ADD COLUMN paid_at TIMESTAMP NULL COMMENT 'Waktu cicilan lunas' AFTER status;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS payments (
+	id UUID NOT NULL PRIMARY KEY,
+	transaction_id UUID NOT NULL REFERENCES transactions(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+	user_id UUID NOT NULL REFERENCES users(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+	amount DECIMAL(15,2) NOT NULL COMMENT 'Nominal yang diterima',
+	applied_amount DECIMAL(15,2) NOT NULL COMMENT 'Nominal yang dialokasikan ke cicilan',
+	excess_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Kelebihan bayar yang tidak dialokasikan',
+	reference VARCHAR(255) NULL COMMENT 'Nomor referensi pembayaran',
+	recorded_by UUID NOT NULL COMMENT 'User yang mencatat pembayaran',
+	paid_at TIMESTAMP NOT NULL COMMENT 'Waktu pembayaran',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	INDEX idx_payments_transaction (transaction_id, paid_at desc)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS payment_allocations (
+	id UUID NOT NULL PRIMARY KEY,
+	payment_id UUID NOT NULL REFERENCES payments(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	installment_id UUID NOT NULL REFERENCES installments(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+	principal_amount DECIMAL(15,2) NOT NULL COMMENT 'Porsi pokok yang dibayar',
+	interest_amount DECIMAL(15,2) NOT NULL COMMENT 'Porsi bunga yang dibayar',
+	fee_amount DECIMAL(15,2) NOT NULL COMMENT 'Porsi admin fee yang dibayar',
+	amount DECIMAL(15,2) NOT NULL COMMENT 'Total yang dialokasikan',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	INDEX idx_payment_allocations_installment (installment_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_allocations;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payments;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE installments
+	DROP COLUMN paid_at,
+	DROP COLUMN amount_paid,
+	DROP COLUMN fee_paid,
+	DROP COLUMN interest_paid,
+	DROP COLUMN principal_paid;
+-- +goose StatementEnd
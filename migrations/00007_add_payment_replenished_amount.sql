@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE payments
+	ADD COLUMN replenished_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Nominal yang dikembalikan ke limit tenor' AFTER excess_amount;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE payments
+	DROP COLUMN replenished_amount;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE installments
+	ADD COLUMN penalty_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Total denda keterlambatan' AFTER amount_due,
+	ADD COLUMN penalty_paid DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Denda yang sudah dibayar' AFTER fee_paid;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE payment_allocations
+	ADD COLUMN penalty_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Porsi denda yang dibayar' AFTER fee_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	ADD COLUMN penalty_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Total denda keterlambatan' AFTER interest_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS installment_penalties (
+	id UUID NOT NULL PRIMARY KEY,
+	installment_id UUID NOT NULL REFERENCES installments(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	transaction_id UUID NOT NULL REFERENCES transactions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	accrual_date DATE NOT NULL COMMENT 'Tanggal denda dihitung',
+	days_overdue INT NOT NULL COMMENT 'Jumlah hari keterlambatan',
+	base_amount DECIMAL(15,2) NOT NULL COMMENT 'Sisa tagihan yang menjadi dasar perhitungan',
+	amount DECIMAL(15,2) NOT NULL COMMENT 'Nominal denda',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	INDEX idx_installment_penalties_transaction (transaction_id, accrual_date),
+	UNIQUE KEY unique_installment_penalty (installment_id, accrual_date)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS installment_penalties;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	DROP COLUMN penalty_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE payment_allocations
+	DROP COLUMN penalty_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE installments
+	DROP COLUMN penalty_paid,
+	DROP COLUMN penalty_amount;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions
+	MODIFY COLUMN status ENUM('pending', 'approved', 'rejected', 'settled') NOT NULL DEFAULT 'pending';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE installments
+	MODIFY COLUMN status ENUM('unpaid', 'partial', 'paid', 'settled') NOT NULL DEFAULT 'unpaid';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS settlement_quotes (
+	id UUID NOT NULL PRIMARY KEY,
+	transaction_id UUID NOT NULL REFERENCES transactions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	remaining_principal DECIMAL(15,2) NOT NULL COMMENT 'Sisa pokok',
+	interest_charged DECIMAL(15,2) NOT NULL COMMENT 'Bunga yang ditagihkan',
+	interest_waived DECIMAL(15,2) NOT NULL COMMENT 'Bunga yang dihapuskan',
+	fee_amount DECIMAL(15,2) NOT NULL COMMENT 'Sisa admin fee',
+	penalty_amount DECIMAL(15,2) NOT NULL COMMENT 'Sisa denda',
+	termination_fee DECIMAL(15,2) NOT NULL COMMENT 'Biaya pelunasan dipercepat',
+	total_amount DECIMAL(15,2) NOT NULL COMMENT 'Total pelunasan',
+	status ENUM('active', 'used') NOT NULL DEFAULT 'active',
+	payment_id UUID NULL COMMENT 'Pembayaran pelunasan',
+	expires_at TIMESTAMP NOT NULL COMMENT 'Batas waktu quote',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	INDEX idx_settlement_quotes_transaction (transaction_id, created_at desc)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS settlement_quotes;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE installments
+	MODIFY COLUMN status ENUM('unpaid', 'partial', 'paid') NOT NULL DEFAULT 'unpaid';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	MODIFY COLUMN status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending';
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions
+	MODIFY COLUMN status ENUM('pending', 'approved', 'rejected', 'settled', 'cancelled') NOT NULL DEFAULT 'pending',
+	ADD COLUMN cancelled_at TIMESTAMP NULL COMMENT 'Waktu pembatalan oleh user' AFTER reviewed_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions
+	DROP COLUMN cancelled_at,
+	MODIFY COLUMN status ENUM('pending', 'approved', 'rejected', 'settled') NOT NULL DEFAULT 'pending';
+-- +goose StatementEnd
@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS pricing_versions (
+	id UUID NOT NULL PRIMARY KEY,
+	product_code VARCHAR(50) NOT NULL COMMENT 'Kode produk',
+	version INT NOT NULL COMMENT 'Nomor versi per produk',
+	effective_from TIMESTAMP NOT NULL COMMENT 'Versi berlaku mulai tanggal ini',
+	interest_method ENUM('flat', 'effective') NOT NULL DEFAULT 'flat' COMMENT 'Metode perhitungan bunga',
+	admin_fee_type ENUM('percent', 'fixed') NOT NULL DEFAULT 'percent',
+	admin_fee_value DECIMAL(15,2) NOT NULL COMMENT 'Persen atau nominal admin fee',
+	admin_fee_base ENUM('principal', 'principal_interest') NOT NULL DEFAULT 'principal' COMMENT 'Dasar perhitungan admin fee persen',
+	admin_fee_min DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Minimal admin fee, 0 jika tidak ada',
+	admin_fee_max DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Maksimal admin fee, 0 jika tidak ada',
+	created_by UUID NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	UNIQUE INDEX idx_pricing_versions_product (product_code, version),
+	INDEX idx_pricing_versions_effective (product_code, effective_from desc)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS pricing_rates (
+	id UUID NOT NULL PRIMARY KEY,
+	pricing_version_id UUID NOT NULL REFERENCES pricing_versions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tenor INT NOT NULL COMMENT 'Tenor dalam bulan',
+	interest_rate DECIMAL(7,4) NOT NULL COMMENT 'Bunga per tahun dalam persen',
+
+	UNIQUE INDEX idx_pricing_rates_tenor (pricing_version_id, tenor)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Versi awal sama dengan perhitungan sebelumnya: bunga 2% dari OTR dan admin fee 1% dari OTR + bunga
+INSERT IGNORE INTO pricing_versions (
+	id, product_code, version, effective_from, interest_method,
+	admin_fee_type, admin_fee_value, admin_fee_base, admin_fee_min, admin_fee_max
+)
+VALUES
+	('01970000-0000-7000-8000-000000000001', 'default', 1, '2025-01-01 00:00:00', 'flat', 'percent', 1, 'principal_interest', 0, 0);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT IGNORE INTO pricing_rates (id, pricing_version_id, tenor, interest_rate)
+VALUES
+	('01970000-0000-7000-8000-000000000101', '01970000-0000-7000-8000-000000000001', 1, 24),
+	('01970000-0000-7000-8000-000000000102', '01970000-0000-7000-8000-000000000001', 2, 12),
+	('01970000-0000-7000-8000-000000000103', '01970000-0000-7000-8000-000000000001', 3, 8),
+	('01970000-0000-7000-8000-000000000104', '01970000-0000-7000-8000-000000000001', 4, 6),
+	('01970000-0000-7000-8000-000000000105', '01970000-0000-7000-8000-000000000001', 5, 4.8),
+	('01970000-0000-7000-8000-000000000106', '01970000-0000-7000-8000-000000000001', 6, 4);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	ADD COLUMN product_code VARCHAR(50) NOT NULL DEFAULT 'default' COMMENT 'Kode produk' AFTER asset_name,
+	ADD COLUMN pricing_version_id UUID NULL COMMENT 'Versi pricing yang digunakan' AFTER product_code;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions
+	DROP COLUMN pricing_version_id,
+	DROP COLUMN product_code;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pricing_rates;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pricing_versions;
+-- +goose StatementEnd
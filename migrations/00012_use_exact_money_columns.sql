@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_tenor_limits
+	MODIFY COLUMN limit_amount DECIMAL(15,2) NOT NULL COMMENT 'Jumlah limit untuk tenor ini';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	MODIFY COLUMN otr DECIMAL(15,2) NOT NULL COMMENT 'Nominal on the road',
+	MODIFY COLUMN admin_fee DECIMAL(15,2) NOT NULL COMMENT 'Admin fee',
+	MODIFY COLUMN installment_amount DECIMAL(15,2) NOT NULL COMMENT 'Jumlah Cicilan per bulan',
+	MODIFY COLUMN interest_amount DECIMAL(15,2) NOT NULL COMMENT 'Total Bunga yang ditagihkan';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions
+	MODIFY COLUMN otr DECIMAL NOT NULL COMMENT 'Nominal on the road',
+	MODIFY COLUMN admin_fee DECIMAL NOT NULL COMMENT 'Admin fee',
+	MODIFY COLUMN installment_amount DECIMAL NOT NULL COMMENT 'Jumlah Cicilan per bulan',
+	MODIFY COLUMN interest_amount DECIMAL NOT NULL COMMENT 'Total Bunga yang ditagihkan';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_tenor_limits
+	MODIFY COLUMN limit_amount DECIMAL NOT NULL COMMENT 'Jumlah limit untuk tenor ini';
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS contract_sequences (
+	sequence_key VARCHAR(191) NOT NULL PRIMARY KEY COMMENT 'Pola nomor kontrak tanpa nomor urut, misalnya DEFAULT-001-2025-{seq:6}',
+	last_number BIGINT NOT NULL DEFAULT 0 COMMENT 'Nomor urut terakhir yang dipakai',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS contract_sequences;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS limit_holds (
+	id UUID NOT NULL PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tenor INT NOT NULL COMMENT 'Tenor dalam bulan',
+	otr DECIMAL(15,2) NOT NULL COMMENT 'Nominal on the road',
+	admin_fee DECIMAL(15,2) NOT NULL COMMENT 'Admin fee',
+	installment_amount DECIMAL(15,2) NOT NULL COMMENT 'Jumlah Cicilan per bulan',
+	interest_amount DECIMAL(15,2) NOT NULL COMMENT 'Total Bunga yang ditagihkan',
+	amount DECIMAL(15,2) NOT NULL COMMENT 'Jumlah limit yang ditahan (OTR + bunga + admin fee)',
+	asset_name VARCHAR(255) NOT NULL,
+	product_code VARCHAR(50) NOT NULL COMMENT 'Kode produk',
+	pricing_version_id UUID NULL COMMENT 'Versi pricing saat hold dibuat',
+	reference VARCHAR(255) NULL COMMENT 'Referensi checkout dari merchant',
+	status ENUM('active', 'captured', 'released', 'expired') NOT NULL DEFAULT 'active',
+	transaction_id UUID NULL COMMENT 'Transaksi hasil capture',
+	expires_at TIMESTAMP NOT NULL COMMENT 'Hold tidak berlaku setelah waktu ini',
+	captured_at TIMESTAMP NULL,
+	released_at TIMESTAMP NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	INDEX idx_limit_holds_active (user_id, tenor, status, expires_at),
+	INDEX idx_limit_holds_expires (status, expires_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS limit_holds;
+-- +goose StatementEnd
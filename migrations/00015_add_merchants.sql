@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS merchants (
+	id UUID NOT NULL PRIMARY KEY,
+	code VARCHAR(50) NOT NULL UNIQUE COMMENT 'Kode merchant',
+	name VARCHAR(255) NOT NULL,
+	type ENUM('dealer', 'store', 'online') NOT NULL COMMENT 'Dealer, toko offline atau toko online',
+	address VARCHAR(255) NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE COMMENT 'Merchant tidak aktif tidak bisa dipakai untuk transaksi baru',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	ADD COLUMN merchant_id UUID NULL COMMENT 'Merchant tempat pembelian' AFTER pricing_version_id,
+	ADD COLUMN sales_channel ENUM('offline', 'online', 'app') NULL COMMENT 'Channel penjualan' AFTER merchant_id,
+	ADD CONSTRAINT fk_transactions_merchant FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON UPDATE CASCADE,
+	ADD INDEX idx_transactions_merchant (merchant_id, transaction_date);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE limit_holds
+	ADD COLUMN merchant_id UUID NULL COMMENT 'Merchant tempat pembelian' AFTER pricing_version_id,
+	ADD COLUMN sales_channel ENUM('offline', 'online', 'app') NULL COMMENT 'Channel penjualan' AFTER merchant_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE limit_holds
+	DROP COLUMN sales_channel,
+	DROP COLUMN merchant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	DROP FOREIGN KEY fk_transactions_merchant,
+	DROP INDEX idx_transactions_merchant,
+	DROP COLUMN sales_channel,
+	DROP COLUMN merchant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS merchants;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS asset_categories (
+	id UUID NOT NULL PRIMARY KEY,
+	code VARCHAR(50) NOT NULL UNIQUE COMMENT 'Kode kategori aset',
+	name VARCHAR(255) NOT NULL,
+	tenors JSON NOT NULL COMMENT 'Daftar tenor yang diperbolehkan dalam bulan',
+	min_otr DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Minimal OTR',
+	max_otr DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Maksimal OTR, 0 jika tidak ada',
+	min_down_payment_percent DECIMAL(5,2) NOT NULL DEFAULT 0 COMMENT 'Minimal uang muka dalam persen dari OTR',
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT IGNORE INTO asset_categories (id, code, name, tenors, min_otr, max_otr, min_down_payment_percent)
+VALUES
+	('01970000-0000-7000-8000-000000000201', 'white_goods', 'White Goods', '[1, 2, 3, 6]', 500000, 30000000, 0),
+	('01970000-0000-7000-8000-000000000202', 'electronics', 'Electronics', '[1, 2, 3, 6]', 100000, 30000000, 0),
+	('01970000-0000-7000-8000-000000000203', 'motorcycle', 'Motorcycle', '[6, 12, 18, 24, 36]', 5000000, 80000000, 10),
+	('01970000-0000-7000-8000-000000000204', 'car', 'Car', '[12, 24, 36, 48, 60]', 50000000, 0, 20);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	ADD COLUMN asset_category VARCHAR(50) NULL COMMENT 'Kode kategori aset' AFTER asset_name;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE limit_holds
+	ADD COLUMN asset_category VARCHAR(50) NULL COMMENT 'Kode kategori aset' AFTER asset_name;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE limit_holds
+	DROP COLUMN asset_category;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	DROP COLUMN asset_category;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS asset_categories;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions
+	ADD COLUMN down_payment DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Uang muka, OTR dikurangi uang muka yang dibiayai' AFTER otr;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE limit_holds
+	ADD COLUMN down_payment DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Uang muka' AFTER otr;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE pricing_versions
+	ADD COLUMN min_down_payment_percent DECIMAL(5,2) NOT NULL DEFAULT 0 COMMENT 'Minimal uang muka dalam persen dari OTR' AFTER admin_fee_max;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pricing_versions
+	DROP COLUMN min_down_payment_percent;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE limit_holds
+	DROP COLUMN down_payment;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE transactions
+	DROP COLUMN down_payment;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS transaction_status_history (
+	id UUID NOT NULL PRIMARY KEY,
+	transaction_id UUID NOT NULL REFERENCES transactions(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	from_status ENUM('pending', 'approved', 'rejected', 'settled', 'cancelled') NULL COMMENT 'Status sebelumnya, kosong saat transaksi dibuat',
+	to_status ENUM('pending', 'approved', 'rejected', 'settled', 'cancelled') NOT NULL COMMENT 'Status baru',
+	actor_id UUID NOT NULL COMMENT 'User yang mengubah status',
+	reason VARCHAR(255) NULL COMMENT 'Alasan perubahan status',
+	changed_at TIMESTAMP NOT NULL COMMENT 'Waktu status berubah',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+	INDEX idx_transaction_status_history_transaction (transaction_id, changed_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transaction_status_history;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_tenor_limits
+	ADD COLUMN version INT NOT NULL DEFAULT 0 COMMENT 'Bertambah setiap limit berubah, untuk optimistic locking' AFTER limit_amount;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_tenor_limits
+	DROP COLUMN version;
+-- +goose StatementEnd
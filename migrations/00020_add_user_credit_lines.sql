@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_credit_lines (
+	id UUID NOT NULL PRIMARY KEY,
+	user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	limit_amount DECIMAL(15,2) NOT NULL COMMENT 'Jumlah limit utama, batas total semua tenor',
+	version INT NOT NULL DEFAULT 0 COMMENT 'Bertambah setiap limit berubah, untuk optimistic locking',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- limit utama user yang sudah ada adalah total limit tenornya, sehingga limit yang bisa dipakai tidak berubah
+INSERT IGNORE INTO user_credit_lines (id, user_id, limit_amount, created_at, updated_at)
+SELECT UUID(), user_id, SUM(limit_amount), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM user_tenor_limits
+GROUP BY user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_credit_lines;
+-- +goose StatementEnd
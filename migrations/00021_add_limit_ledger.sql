@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS limit_ledger (
+	id UUID NOT NULL PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tenor_limit_id UUID NOT NULL REFERENCES user_tenor_limits(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tenor INT NOT NULL COMMENT 'Tenor dalam bulan',
+	type ENUM('debit', 'credit') NOT NULL COMMENT 'Debit mengurangi limit, credit menambah limit',
+	source_type ENUM('opening', 'transaction', 'rejection', 'cancellation', 'payment', 'adjustment') NOT NULL COMMENT 'Jenis sumber perubahan limit',
+	source_id UUID NOT NULL COMMENT 'ID transaksi, pembayaran, limit tenor (opening) atau admin (adjustment)',
+	amount DECIMAL(15,2) NOT NULL COMMENT 'Jumlah perubahan, selalu positif',
+	balance_after DECIMAL(15,2) NOT NULL COMMENT 'Limit tenor setelah perubahan',
+	credit_line_balance_after DECIMAL(15,2) NOT NULL COMMENT 'Limit utama setelah perubahan',
+	reason VARCHAR(255) NULL COMMENT 'Alasan adjustment',
+	created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+
+	INDEX idx_limit_ledger_tenor (user_id, tenor, created_at),
+	INDEX idx_limit_ledger_source (source_type, source_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- saldo awal setiap limit tenor, sehingga saldo ledger sama dengan limit saat ini
+INSERT INTO limit_ledger (id, user_id, tenor_limit_id, tenor, type, source_type, source_id, amount, balance_after, credit_line_balance_after, created_at)
+SELECT UUID(), l.user_id, l.id, l.tenor_in_months, 'credit', 'opening', l.id, l.limit_amount, l.limit_amount, COALESCE(c.limit_amount, 0), CURRENT_TIMESTAMP(6)
+FROM user_tenor_limits l
+LEFT JOIN user_credit_lines c ON c.user_id = l.user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS limit_ledger;
+-- +goose StatementEnd
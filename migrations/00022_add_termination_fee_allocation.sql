@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- biaya pelunasan dipercepat dicatat sebagai alokasi sendiri tanpa cicilan
+ALTER TABLE payment_allocations
+	MODIFY COLUMN installment_id UUID NULL,
+	ADD COLUMN termination_fee_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Porsi biaya pelunasan dipercepat yang dibayar' AFTER penalty_amount;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM payment_allocations WHERE installment_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE payment_allocations
+	DROP COLUMN termination_fee_amount,
+	MODIFY COLUMN installment_id UUID NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_tenor_limits
+	ADD COLUMN granted_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Limit yang diberikan, hanya berubah oleh adjustment admin' AFTER limit_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_credit_lines
+	ADD COLUMN granted_amount DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Limit utama yang diberikan, hanya berubah oleh adjustment admin' AFTER limit_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- limit yang diberikan adalah limit saat ini ditambah transaksi yang belum dikembalikan ke limit
+UPDATE user_tenor_limits l
+SET l.granted_amount = l.limit_amount + (
+	SELECT COALESCE(SUM(t.otr - t.down_payment + t.interest_amount + t.admin_fee - (
+		SELECT COALESCE(SUM(p.replenished_amount), 0) FROM payments p WHERE p.transaction_id = t.id
+	)), 0)
+	FROM transactions t
+	WHERE t.user_id = l.user_id AND t.tenor = l.tenor_in_months AND t.status NOT IN ('rejected', 'cancelled')
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE user_credit_lines c
+SET c.granted_amount = c.limit_amount + (
+	SELECT COALESCE(SUM(t.otr - t.down_payment + t.interest_amount + t.admin_fee - (
+		SELECT COALESCE(SUM(p.replenished_amount), 0) FROM payments p WHERE p.transaction_id = t.id
+	)), 0)
+	FROM transactions t
+	WHERE t.user_id = c.user_id AND t.status NOT IN ('rejected', 'cancelled')
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- koreksi rekonsiliasi limit utama saja tidak punya limit tenor
+ALTER TABLE limit_ledger
+	MODIFY COLUMN tenor_limit_id UUID NULL,
+	MODIFY COLUMN source_type ENUM('opening', 'transaction', 'rejection', 'cancellation', 'payment', 'adjustment', 'reconciliation') NOT NULL COMMENT 'Jenis sumber perubahan limit';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM limit_ledger WHERE source_type = 'reconciliation';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE limit_ledger
+	MODIFY COLUMN source_type ENUM('opening', 'transaction', 'rejection', 'cancellation', 'payment', 'adjustment') NOT NULL COMMENT 'Jenis sumber perubahan limit',
+	MODIFY COLUMN tenor_limit_id UUID NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_credit_lines
+	DROP COLUMN granted_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_tenor_limits
+	DROP COLUMN granted_amount;
+-- +goose StatementEnd
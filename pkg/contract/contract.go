@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPattern is used when the configured pattern is empty or invalid
+const DefaultPattern = "{product}-{branch}-{year}-{seq:6}"
+
+var ErrInvalidPattern = errors.New("contract pattern must contain exactly one {seq} placeholder")
+
+var seqRegex = regexp.MustCompile(`\{seq(?::(\d+))?}`)
+
+// Values are the values of the pattern placeholders
+type Values struct {
+	Product string
+	Branch  string
+	Date    time.Time
+}
+
+// Validate checks if the pattern can generate unique contract numbers
+func Validate(pattern string) error {
+	if len(seqRegex.FindAllString(pattern, -1)) != 1 {
+		return ErrInvalidPattern
+	}
+	return nil
+}
+
+// Key renders every placeholder of the pattern except the running number.
+//
+// The key is the period of the running number, e.g. "MOTOR-001-2025-{seq:6}" restarts from 1 every year
+func Key(pattern string, v Values) string {
+	return strings.NewReplacer(
+		"{product}", strings.ToUpper(v.Product),
+		"{branch}", strings.ToUpper(v.Branch),
+		"{year}", v.Date.Format("2006"),
+		"{yy}", v.Date.Format("06"),
+		"{month}", v.Date.Format("01"),
+	).Replace(pattern)
+}
+
+// Format renders the contract number of the key with running number seq, followed by the check digit.
+//
+// `{seq:N}` pads the running number with zero to N digits, `{seq}` doesn't pad
+func Format(key string, seq int64) string {
+	number := seqRegex.ReplaceAllStringFunc(key, func(s string) string {
+		width := 0
+		if m := seqRegex.FindStringSubmatch(s); m[1] != "" {
+			width, _ = strconv.Atoi(m[1])
+		}
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+	return number + strconv.Itoa(CheckDigit(number))
+}
+
+// CheckDigit calculates Luhn mod 10 check digit of s.
+//
+// Letters are converted to 10-35 (A-Z) like ISIN, other characters are ignored
+func CheckDigit(s string) int {
+	var digits []int
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		case r >= 'A' && r <= 'Z':
+			v := int(r-'A') + 10
+			digits = append(digits, v/10, v%10)
+		}
+	}
+
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		// double every second digit from the right, starting with the rightmost digit
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+// Valid checks the check digit of the contract number
+func Valid(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+	last := number[len(number)-1]
+	if last < '0' || last > '9' {
+		return false
+	}
+	return CheckDigit(number[:len(number)-1]) == int(last-'0')
+}
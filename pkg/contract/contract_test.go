@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package contract
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(DefaultPattern))
+	assert.NoError(t, Validate("KP{yy}{month}{seq}"))
+	assert.ErrorIs(t, Validate("{product}-{year}"), ErrInvalidPattern)
+	assert.ErrorIs(t, Validate("{seq}-{seq:4}"), ErrInvalidPattern)
+}
+
+func TestFormat(t *testing.T) {
+	values := Values{Product: "motor", Branch: "jkt01", Date: time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)}
+
+	cases := []struct {
+		pattern  string
+		seq      int64
+		key      string
+		expected string
+	}{
+		{pattern: DefaultPattern, seq: 42, key: "MOTOR-JKT01-2025-{seq:6}", expected: "MOTOR-JKT01-2025-000042"},
+		{pattern: "KP{yy}{month}{seq:4}", seq: 7, key: "KP2507{seq:4}", expected: "KP25070007"},
+		{pattern: "KP{yy}{month}{seq:4}", seq: 123456, key: "KP2507{seq:4}", expected: "KP2507123456"},
+		{pattern: "{branch}/{seq}", seq: 15, key: "JKT01/{seq}", expected: "JKT01/15"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.pattern, func(t *testing.T) {
+			key := Key(c.pattern, values)
+			assert.Equal(t, c.key, key)
+
+			number := Format(key, c.seq)
+			assert.Equal(t, c.expected, number[:len(number)-1])
+			assert.True(t, Valid(number), number)
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	// Luhn test number
+	assert.Equal(t, 3, CheckDigit("7992739871"))
+	assert.True(t, Valid("79927398713"))
+	// ISIN US0378331005
+	assert.Equal(t, 5, CheckDigit("US037833100"))
+	assert.True(t, Valid("US0378331005"))
+
+	// single digit typo and adjacent transposition are detected
+	assert.False(t, Valid("79927398714"))
+	assert.False(t, Valid("79927398173"))
+	assert.False(t, Valid("7"))
+	assert.False(t, Valid("KP-X"))
+}
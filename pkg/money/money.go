@@ -0,0 +1,313 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// scale number of sen in one rupiah. All money columns use DECIMAL(x,2)
+const scale = 100
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Money is an exact amount of rupiah, stored as integer sen (1/100 rupiah).
+//
+// Zero value is 0 rupiah. Use New, FromSen, FromFloat or Parse to create the value
+type Money struct {
+	sen int64
+}
+
+// New creates money from whole rupiah
+func New(rupiah int64) Money {
+	return Money{sen: rupiah * scale}
+}
+
+// FromSen creates money from sen
+func FromSen(sen int64) Money {
+	return Money{sen: sen}
+}
+
+// FromFloat creates money from float rupiah, rounded half away from zero to sen.
+//
+// Only use it on the edge of the system, e.g. configuration values
+func FromFloat(rupiah float64) Money {
+	return Money{sen: int64(math.Round(rupiah * scale))}
+}
+
+// Parse parses decimal string like "1500000" or "1500000.50" exactly.
+// Amount with more than 2 decimals is rejected
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidAmount
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" && (!hasFrac || frac == "") {
+		return Money{}, ErrInvalidAmount
+	}
+	if hasFrac {
+		// allow trailing zero from DECIMAL column with bigger scale, e.g. 100.5000
+		frac = strings.TrimRight(frac, "0")
+	}
+	if len(frac) > 2 {
+		return Money{}, fmt.Errorf("%w: more than 2 decimals", ErrInvalidAmount)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	if whole == "" {
+		whole = "0"
+	}
+	for _, c := range whole + frac {
+		if c < '0' || c > '9' {
+			return Money{}, ErrInvalidAmount
+		}
+	}
+
+	sen, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %s", ErrInvalidAmount, err.Error())
+	}
+	if neg {
+		sen = -sen
+	}
+	return Money{sen: sen}, nil
+}
+
+// Sum adds all values
+func Sum(values ...Money) Money {
+	var total int64
+	for _, v := range values {
+		total += v.sen
+	}
+	return Money{sen: total}
+}
+
+// Min returns the smaller value
+func Min(a, b Money) Money {
+	if a.sen < b.sen {
+		return a
+	}
+	return b
+}
+
+// Max returns the bigger value
+func Max(a, b Money) Money {
+	if a.sen > b.sen {
+		return a
+	}
+	return b
+}
+
+// Sen returns the amount in sen
+func (m Money) Sen() int64 {
+	return m.sen
+}
+
+// Float64 returns the amount in rupiah as float. It must not be used for calculation
+func (m Money) Float64() float64 {
+	return float64(m.sen) / scale
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{sen: m.sen + o.sen}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{sen: m.sen - o.sen}
+}
+
+// Mul multiplies the amount with integer n
+func (m Money) Mul(n int64) Money {
+	return Money{sen: m.sen * n}
+}
+
+// MulRat multiplies the amount with r, rounded half away from zero to sen
+func (m Money) MulRat(r *big.Rat) Money {
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(m.sen), r)
+	return Money{sen: roundRat(v)}
+}
+
+// MulFloat multiplies the amount with f, rounded half away from zero to sen.
+//
+// f is converted with its shortest decimal representation, so 4.8 is treated as exactly 4.8
+func (m Money) MulFloat(f float64) Money {
+	return m.MulRat(Rat(f))
+}
+
+// Percent returns p percent of the amount, rounded half away from zero to sen
+func (m Money) Percent(p float64) Money {
+	return m.MulRat(new(big.Rat).Quo(Rat(p), big.NewRat(100, 1)))
+}
+
+// Div divides the amount with n, rounded half away from zero to sen
+func (m Money) Div(n int64) Money {
+	return m.MulRat(big.NewRat(1, n))
+}
+
+// Split splits the amount into n parts of whole sen.
+//
+// The residual is placed on the last part, so the sum of all parts is always equal to the amount
+func (m Money) Split(n int) []Money {
+	return m.Allocate(n, Rounding{})
+}
+
+// Cmp compares the amount, returns -1 if m < o, 0 if m == o and +1 if m > o
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.sen < o.sen:
+		return -1
+	case m.sen > o.sen:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) LessThan(o Money) bool {
+	return m.sen < o.sen
+}
+
+func (m Money) GreaterThan(o Money) bool {
+	return m.sen > o.sen
+}
+
+func (m Money) IsZero() bool {
+	return m.sen == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.sen > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.sen < 0
+}
+
+// String returns the amount as decimal string with 2 decimals, e.g. "1500000.50"
+func (m Money) String() string {
+	sen := m.sen
+	sign := ""
+	if sen < 0 {
+		sign = "-"
+		sen = -sen
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, sen/scale, sen%scale)
+}
+
+// MarshalJSON encodes the amount as JSON number, e.g. 1500000.5
+func (m Money) MarshalJSON() ([]byte, error) {
+	s := strings.TrimRight(m.String(), "0")
+	s = strings.TrimSuffix(s, ".")
+	return []byte(s), nil
+}
+
+// UnmarshalJSON decodes JSON number or string
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+
+	// JSON number can use exponent, e.g. 1.5e6
+	if strings.ContainsAny(s, "eE") {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return ErrInvalidAmount
+		}
+		r.Mul(r, big.NewRat(scale, 1))
+		if !r.IsInt() {
+			return fmt.Errorf("%w: more than 2 decimals", ErrInvalidAmount)
+		}
+		if !r.Num().IsInt64() {
+			return fmt.Errorf("%w: amount is too large", ErrInvalidAmount)
+		}
+		m.sen = r.Num().Int64()
+		return nil
+	}
+
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value implements driver.Valuer, the amount is saved as decimal string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner for DECIMAL column
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = New(v)
+	case float64:
+		*m = FromFloat(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Rat converts f to exact rational number with its shortest decimal representation
+func Rat(f float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	return r
+}
+
+// roundRat rounds r half away from zero to integer
+func roundRat(r *big.Rat) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	neg := num.Sign() < 0
+	num.Abs(num)
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if neg {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
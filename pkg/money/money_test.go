@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package money
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected Money
+		err      bool
+	}{
+		{input: "1500000", expected: New(1500000)},
+		{input: "1500000.5", expected: FromSen(150000050)},
+		{input: "1500000.05", expected: FromSen(150000005)},
+		{input: "0.10", expected: FromSen(10)},
+		{input: ".5", expected: FromSen(50)},
+		{input: "-25.75", expected: FromSen(-2575)},
+		{input: "100.5000", expected: FromSen(10050)},
+		{input: "100.555", err: true},
+		{input: "abc", err: true},
+		{input: "1.2.3", err: true},
+		{input: "", err: true},
+		{input: ".", err: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			res, err := Parse(c.input)
+			if c.err {
+				assert.ErrorIs(t, err, ErrInvalidAmount)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, res)
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	// 0.1 + 0.2 must be exactly 0.3
+	assert.Equal(t, FromSen(30), FromSen(10).Add(FromSen(20)))
+	assert.Equal(t, New(175840), New(1000000).Sub(New(824160)))
+	assert.Equal(t, New(16000), New(800000).Percent(2))
+	assert.Equal(t, New(8160), New(816000).Percent(1))
+	assert.Equal(t, FromSen(1235), New(1234).Percent(1.0008))
+	assert.Equal(t, New(9600), New(1200000).MulFloat(0.008))
+	assert.Equal(t, FromSen(33333333), New(1000000).Div(3))
+	assert.Equal(t, FromSen(66666667), New(2000000).Div(3))
+	assert.Equal(t, FromSen(-50), FromSen(-100).Div(2))
+	assert.Equal(t, FromSen(-1), FromSen(-1).Div(2))
+	assert.Equal(t, New(3), Sum(New(1), New(1), New(1)))
+	assert.Equal(t, New(1), Min(New(1), New(2)))
+	assert.Equal(t, New(2), Max(New(1), New(2)))
+	assert.True(t, New(1).LessThan(New(2)))
+	assert.True(t, New(2).GreaterThan(New(1)))
+	assert.Equal(t, 0, New(2).Cmp(FromSen(200)))
+}
+
+func TestMoney_Split(t *testing.T) {
+	for n := 1; n <= 36; n++ {
+		for _, amount := range []Money{New(1000000), FromSen(82416001), FromSen(1), New(0)} {
+			parts := amount.Split(n)
+			assert.Len(t, parts, n)
+			assert.Equal(t, amount, Sum(parts...))
+			for i := 0; i < n-1; i++ {
+				assert.Equal(t, parts[0], parts[i])
+			}
+		}
+	}
+	assert.Nil(t, New(100).Split(0))
+}
+
+func TestMoney_JSON(t *testing.T) {
+	type payload struct {
+		Amount Money `json:"amount"`
+	}
+
+	b, err := json.Marshal(payload{Amount: FromSen(150000050)})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":1500000.5}`, string(b))
+
+	b, err = json.Marshal(payload{Amount: New(-200)})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":-200}`, string(b))
+
+	for input, expected := range map[string]Money{
+		`{"amount":1500000.5}`:   FromSen(150000050),
+		`{"amount":"1500000.5"}`: FromSen(150000050),
+		`{"amount":1.5e6}`:       New(1500000),
+		`{"amount":null}`:        {},
+	} {
+		var p payload
+		assert.NoError(t, json.Unmarshal([]byte(input), &p), input)
+		assert.Equal(t, expected, p.Amount, input)
+	}
+
+	var p payload
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":0.001}`), &p))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":1e-3}`), &p))
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":1e30}`), &p), ErrInvalidAmount)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"99999999999999999999"}`), &p), ErrInvalidAmount)
+}
+
+func TestMoney_SQL(t *testing.T) {
+	v, err := FromSen(150000050).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "1500000.50", v)
+
+	cases := []struct {
+		src      any
+		expected Money
+	}{
+		{src: []byte("824160.00"), expected: New(824160)},
+		{src: "0.30", expected: FromSen(30)},
+		{src: int64(1000000), expected: New(1000000)},
+		{src: 0.3, expected: FromSen(30)},
+		{src: nil, expected: Money{}},
+	}
+	for _, c := range cases {
+		var m Money
+		assert.NoError(t, m.Scan(c.src))
+		assert.Equal(t, c.expected, m)
+	}
+
+	var m Money
+	assert.Error(t, m.Scan(true))
+}
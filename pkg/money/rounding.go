@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package money
+
+import "math/big"
+
+// RoundingMode is the direction of rounding to the rounding unit
+type RoundingMode string
+
+const (
+	// RoundDown rounds toward negative infinity (floor)
+	RoundDown RoundingMode = "down"
+	// RoundUp rounds toward positive infinity (ceiling)
+	RoundUp RoundingMode = "up"
+	// RoundHalfEven rounds to the nearest unit, ties go to the even unit (banker's rounding)
+	RoundHalfEven RoundingMode = "half_even"
+)
+
+const (
+	// ResidualLast puts the rounding residual on the last part
+	ResidualLast = "last"
+	// ResidualFirst puts the rounding residual on the first part
+	ResidualFirst = "first"
+)
+
+// Rounding is the policy used to split an amount into rounded parts.
+//
+// Zero value rounds down to sen with the residual on the last part
+type Rounding struct {
+	// Unit every regular part is a multiple of Unit, zero means 1 sen
+	Unit Money
+	// Mode the rounding direction, empty means RoundDown
+	Mode RoundingMode
+	// Residual the part that takes the residual, ResidualFirst or ResidualLast (default)
+	Residual string
+}
+
+// Round rounds the amount to a multiple of unit using mode. Zero unit means 1 sen
+func (m Money) Round(unit Money, mode RoundingMode) Money {
+	return roundTo(big.NewRat(m.sen, 1), unit, mode)
+}
+
+// Allocate splits the amount into n parts using the rounding policy.
+//
+// Every part except the residual part is amount/n rounded to the policy unit,
+// the residual part takes the rest, so the sum of all parts is always equal to the amount.
+// If rounding up makes the residual part negative, the regular parts are rounded down instead
+func (m Money) Allocate(n int, r Rounding) []Money {
+	if n < 1 {
+		return nil
+	}
+
+	share := big.NewRat(m.sen, int64(n))
+	part := roundTo(share, r.Unit, r.Mode)
+	residual := m.Sub(part.Mul(int64(n - 1)))
+	if residual.sen*m.sen < 0 {
+		part = roundTo(share, r.Unit, RoundDown)
+		residual = m.Sub(part.Mul(int64(n - 1)))
+	}
+
+	parts := make([]Money, n)
+	for i := range parts {
+		parts[i] = part
+	}
+	if r.Residual == ResidualFirst {
+		parts[0] = residual
+	} else {
+		parts[n-1] = residual
+	}
+	return parts
+}
+
+// roundTo rounds sen to a multiple of unit using mode
+func roundTo(sen *big.Rat, unit Money, mode RoundingMode) Money {
+	u := unit.sen
+	if u <= 0 {
+		u = 1
+	}
+
+	q := new(big.Rat).Quo(sen, big.NewRat(u, 1))
+	num, den := q.Num(), q.Denom()
+
+	// big.Int.Div is euclidean division, so the quotient is floor for positive denominator
+	floor, rem := new(big.Int).DivMod(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		switch mode {
+		case RoundUp:
+			floor.Add(floor, big.NewInt(1))
+		case RoundHalfEven:
+			switch rem.Mul(rem, big.NewInt(2)).Cmp(den) {
+			case 1:
+				floor.Add(floor, big.NewInt(1))
+			case 0:
+				if floor.Bit(0) == 1 {
+					floor.Add(floor, big.NewInt(1))
+				}
+			}
+		}
+	}
+	return Money{sen: floor.Int64() * u}
+}
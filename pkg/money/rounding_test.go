@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package money
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/quick"
+)
+
+func TestMoney_Round(t *testing.T) {
+	cases := []struct {
+		amount   Money
+		unit     Money
+		mode     RoundingMode
+		expected Money
+	}{
+		{amount: FromSen(27472050), unit: New(1), mode: RoundDown, expected: New(274720)},
+		{amount: FromSen(27472050), unit: New(1), mode: RoundUp, expected: New(274721)},
+		{amount: FromSen(27472050), unit: New(1), mode: RoundHalfEven, expected: New(274720)},
+		{amount: FromSen(27472150), unit: New(1), mode: RoundHalfEven, expected: New(274722)},
+		{amount: New(274750), unit: New(100), mode: RoundHalfEven, expected: New(274800)},
+		{amount: New(274650), unit: New(100), mode: RoundHalfEven, expected: New(274600)},
+		{amount: New(274651), unit: New(100), mode: RoundHalfEven, expected: New(274700)},
+		{amount: New(274720), unit: New(1000), mode: RoundDown, expected: New(274000)},
+		{amount: New(274720), unit: New(1000), mode: RoundUp, expected: New(275000)},
+		{amount: New(275000), unit: New(1000), mode: RoundUp, expected: New(275000)},
+		{amount: New(-1500), unit: New(1000), mode: RoundDown, expected: New(-2000)},
+		{amount: New(-1500), unit: New(1000), mode: RoundHalfEven, expected: New(-2000)},
+		{amount: FromSen(12345), unit: Money{}, mode: RoundUp, expected: FromSen(12345)},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.amount.Round(c.unit, c.mode), "%s to %s %s", c.amount, c.unit, c.mode)
+	}
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	total := New(824160)
+
+	parts := total.Allocate(3, Rounding{Unit: New(1000), Mode: RoundDown, Residual: ResidualLast})
+	assert.Equal(t, []Money{New(274000), New(274000), New(276160)}, parts)
+
+	parts = total.Allocate(3, Rounding{Unit: New(1000), Mode: RoundUp, Residual: ResidualFirst})
+	assert.Equal(t, []Money{New(274160), New(275000), New(275000)}, parts)
+
+	parts = total.Allocate(3, Rounding{Unit: New(100), Mode: RoundHalfEven, Residual: ResidualLast})
+	assert.Equal(t, []Money{New(274700), New(274700), New(274760)}, parts)
+
+	// Rounding up 1000 to unit 1000 for 6 parts makes the residual negative, so it is rounded down
+	parts = New(1000).Allocate(6, Rounding{Unit: New(1000), Mode: RoundUp})
+	assert.Equal(t, []Money{{}, {}, {}, {}, {}, New(1000)}, parts)
+
+	assert.Nil(t, total.Allocate(0, Rounding{}))
+}
+
+// TestMoney_Allocate_Property checks for every tenor and rounding policy that
+// the parts always add back up to the amount exactly
+func TestMoney_Allocate_Property(t *testing.T) {
+	units := []Money{{}, New(1), New(100), New(1000)}
+	modes := []RoundingMode{RoundDown, RoundUp, RoundHalfEven}
+	residuals := []string{ResidualFirst, ResidualLast}
+
+	for n := 1; n <= 60; n++ {
+		for _, unit := range units {
+			for _, mode := range modes {
+				for _, residual := range residuals {
+					policy := Rounding{Unit: unit, Mode: mode, Residual: residual}
+
+					property := func(sen uint32) bool {
+						amount := FromSen(int64(sen))
+						parts := amount.Allocate(n, policy)
+						if len(parts) != n || Sum(parts...) != amount {
+							return false
+						}
+
+						residualIdx, regularIdx := n-1, 0
+						if residual == ResidualFirst {
+							residualIdx, regularIdx = 0, n-1
+						}
+						regular := parts[regularIdx]
+						for i, part := range parts {
+							if i != residualIdx && part != regular {
+								return false
+							}
+						}
+						if n > 1 && unit.IsPositive() && regular.sen%unit.sen != 0 {
+							return false
+						}
+						return !parts[residualIdx].IsNegative()
+					}
+
+					if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+						t.Errorf("tenor %d, policy %+v: %v", n, policy, err)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package pricing
+
+import (
+	"math"
+	"math/big"
+	"xyz/pkg/money"
+)
+
+const (
+	// MethodFlat calculates interest from the initial principal for the whole tenor
+	MethodFlat = "flat"
+	// MethodEffective calculates interest from the remaining principal every month (annuity)
+	MethodEffective = "effective"
+
+	// FeePercent admin fee is percent of the fee base
+	FeePercent = "percent"
+	// FeeFixed admin fee is fixed amount
+	FeeFixed = "fixed"
+
+	// FeeBasePrincipal admin fee is calculated from the principal
+	FeeBasePrincipal = "principal"
+	// FeeBasePrincipalInterest admin fee is calculated from the principal and interest
+	FeeBasePrincipalInterest = "principal_interest"
+)
+
+// Rule is the pricing rule of one product and tenor
+type Rule struct {
+	// Method interest calculation method, MethodFlat or MethodEffective
+	Method string
+	// InterestRate annual interest rate in percent
+	InterestRate float64
+	// FeeType admin fee type, FeePercent or FeeFixed
+	FeeType string
+	// FeeValue percent or amount of admin fee
+	FeeValue float64
+	// FeeBase the amount that FeePercent is calculated from
+	FeeBase string
+	// FeeMin minimum admin fee, 0 means no minimum
+	FeeMin money.Money
+	// FeeMax maximum admin fee, 0 means no maximum
+	FeeMax money.Money
+	// Rounding rounding policy of the monthly installment
+	Rounding money.Rounding
+}
+
+// Result is the calculated amount of the transaction
+type Result struct {
+	Principal         money.Money `json:"principal"`
+	InterestAmount    money.Money `json:"interest_amount"`
+	AdminFee          money.Money `json:"admin_fee"`
+	InstallmentAmount money.Money `json:"installment_amount"`
+	TotalAmount       money.Money `json:"total_amount"`
+}
+
+// Installment is the amount due of one month
+type Installment struct {
+	Principal money.Money
+	Interest  money.Money
+	Fee       money.Money
+	Amount    money.Money
+}
+
+// Calculate calculates interest, admin fee and monthly installment of principal for tenor months
+func Calculate(principal money.Money, tenor int, rule Rule) Result {
+	res := Result{Principal: principal}
+	if tenor < 1 {
+		return res
+	}
+
+	res.InterestAmount = interest(principal, tenor, rule)
+	res.AdminFee = adminFee(principal, res.InterestAmount, rule)
+	res.TotalAmount = money.Sum(principal, res.InterestAmount, res.AdminFee)
+	res.InstallmentAmount = regularInstallment(Schedule(res, tenor, rule.Rounding), rule.Rounding)
+	return res
+}
+
+// Schedule splits the result into tenor monthly installments.
+//
+// The installment amount is rounded with the rounding policy, and the residual goes to the first or last installment,
+// so the sum of installments is always equal to the total amount. Interest and admin fee are split by sen
+// with the residual on the same installment, and principal is the rest of the installment amount.
+// If rounding up leaves negative principal on the residual installment, the amount is rounded down instead
+func Schedule(res Result, tenor int, rounding money.Rounding) []Installment {
+	if tenor < 1 {
+		return nil
+	}
+
+	interests := res.InterestAmount.Allocate(tenor, money.Rounding{Residual: rounding.Residual})
+	fees := res.AdminFee.Allocate(tenor, money.Rounding{Residual: rounding.Residual})
+	amounts := res.TotalAmount.Allocate(tenor, rounding)
+
+	// Rounding up can leave the residual installment smaller than its interest and fee,
+	// round down instead so that no installment has negative principal
+	for i := range amounts {
+		if amounts[i].LessThan(interests[i].Add(fees[i])) {
+			rounding.Mode = money.RoundDown
+			amounts = res.TotalAmount.Allocate(tenor, rounding)
+			break
+		}
+	}
+
+	installments := make([]Installment, tenor)
+	for i := range installments {
+		installments[i] = Installment{
+			Principal: amounts[i].Sub(interests[i]).Sub(fees[i]),
+			Interest:  interests[i],
+			Fee:       fees[i],
+			Amount:    amounts[i],
+		}
+	}
+	return installments
+}
+
+// regularInstallment returns the installment amount that does not take the rounding residual
+func regularInstallment(installments []Installment, rounding money.Rounding) money.Money {
+	if rounding.Residual == money.ResidualFirst {
+		return installments[len(installments)-1].Amount
+	}
+	return installments[0].Amount
+}
+
+func interest(principal money.Money, tenor int, rule Rule) money.Money {
+	if rule.InterestRate <= 0 {
+		return money.Money{}
+	}
+
+	if rule.Method == MethodEffective {
+		monthly := rule.InterestRate / 100 / 12
+		n := float64(tenor)
+		factor := monthly / (1 - math.Pow(1+monthly, -n))
+		return principal.MulFloat(factor * n).Sub(principal)
+	}
+
+	// principal * rate / 100 * tenor / 12
+	rate := new(big.Rat).Mul(money.Rat(rule.InterestRate), big.NewRat(int64(tenor), 1200))
+	return principal.MulRat(rate)
+}
+
+func adminFee(principal, interest money.Money, rule Rule) money.Money {
+	var fee money.Money
+	if rule.FeeType == FeeFixed {
+		fee = money.FromFloat(rule.FeeValue)
+	} else {
+		base := principal
+		if rule.FeeBase == FeeBasePrincipalInterest {
+			base = base.Add(interest)
+		}
+		fee = base.Percent(rule.FeeValue)
+	}
+
+	if rule.FeeMin.IsPositive() && fee.LessThan(rule.FeeMin) {
+		fee = rule.FeeMin
+	}
+	if rule.FeeMax.IsPositive() && fee.GreaterThan(rule.FeeMax) {
+		fee = rule.FeeMax
+	}
+	return fee
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) - All Rights Reserved
+ * Unauthorized copying of this file, via any medium is strictly prohibited
+ * Proprietary and confidential
+ * Written by Putu Aditya <aditya@portalnesia.com>
+ */
+
+package pricing
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/quick"
+	"xyz/pkg/money"
+)
+
+func TestCalculate(t *testing.T) {
+	cases := []struct {
+		name      string
+		principal money.Money
+		tenor     int
+		rule      Rule
+		expected  Result
+	}{
+		{
+			name:      "Flat rate with percent fee from principal and interest",
+			principal: money.New(800000),
+			tenor:     3,
+			rule:      Rule{Method: MethodFlat, InterestRate: 8, FeeType: FeePercent, FeeValue: 1, FeeBase: FeeBasePrincipalInterest},
+			expected:  Result{Principal: money.New(800000), InterestAmount: money.New(16000), AdminFee: money.New(8160), TotalAmount: money.New(824160), InstallmentAmount: money.New(274720)},
+		},
+		{
+			name:      "Flat rate with percent fee from principal",
+			principal: money.New(1200000),
+			tenor:     6,
+			rule:      Rule{Method: MethodFlat, InterestRate: 12, FeeType: FeePercent, FeeValue: 2, FeeBase: FeeBasePrincipal},
+			expected:  Result{Principal: money.New(1200000), InterestAmount: money.New(72000), AdminFee: money.New(24000), TotalAmount: money.New(1296000), InstallmentAmount: money.New(216000)},
+		},
+		{
+			name:      "Effective rate",
+			principal: money.New(1200000),
+			tenor:     12,
+			rule:      Rule{Method: MethodEffective, InterestRate: 12, FeeType: FeeFixed, FeeValue: 50000},
+			expected:  Result{Principal: money.New(1200000), InterestAmount: money.FromSen(7942256), AdminFee: money.New(50000), TotalAmount: money.FromSen(132942256), InstallmentAmount: money.FromSen(11078521)},
+		},
+		{
+			name:      "Zero interest",
+			principal: money.New(600000),
+			tenor:     3,
+			rule:      Rule{Method: MethodEffective, FeeType: FeeFixed, FeeValue: 10000},
+			expected:  Result{Principal: money.New(600000), AdminFee: money.New(10000), TotalAmount: money.New(610000), InstallmentAmount: money.FromSen(20333333)},
+		},
+		{
+			name:      "Admin fee below minimum",
+			principal: money.New(100000),
+			tenor:     1,
+			rule:      Rule{Method: MethodFlat, FeeType: FeePercent, FeeValue: 1, FeeMin: money.New(5000)},
+			expected:  Result{Principal: money.New(100000), AdminFee: money.New(5000), TotalAmount: money.New(105000), InstallmentAmount: money.New(105000)},
+		},
+		{
+			name:      "Admin fee above maximum",
+			principal: money.New(100000000),
+			tenor:     1,
+			rule:      Rule{Method: MethodFlat, FeeType: FeePercent, FeeValue: 1, FeeMax: money.New(500000)},
+			expected:  Result{Principal: money.New(100000000), AdminFee: money.New(500000), TotalAmount: money.New(100500000), InstallmentAmount: money.New(100500000)},
+		},
+		{
+			name:      "Invalid tenor",
+			principal: money.New(100000),
+			tenor:     0,
+			rule:      Rule{Method: MethodFlat, InterestRate: 12},
+			expected:  Result{Principal: money.New(100000)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, Calculate(c.principal, c.tenor, c.rule))
+		})
+	}
+}
+
+func TestCalculate_Rounding(t *testing.T) {
+	rule := Rule{Method: MethodFlat, InterestRate: 8, FeeType: FeePercent, FeeValue: 1, FeeBase: FeeBasePrincipalInterest}
+
+	rule.Rounding = money.Rounding{Unit: money.New(1000), Mode: money.RoundUp, Residual: money.ResidualLast}
+	res := Calculate(money.New(800000), 3, rule)
+	assert.Equal(t, money.New(275000), res.InstallmentAmount)
+
+	installments := Schedule(res, 3, rule.Rounding)
+	assert.Equal(t, []Installment{
+		{Principal: money.FromSen(26694667), Interest: money.FromSen(533333), Fee: money.New(2720), Amount: money.New(275000)},
+		{Principal: money.FromSen(26694667), Interest: money.FromSen(533333), Fee: money.New(2720), Amount: money.New(275000)},
+		{Principal: money.FromSen(26610666), Interest: money.FromSen(533334), Fee: money.New(2720), Amount: money.New(274160)},
+	}, installments)
+
+	rule.Rounding = money.Rounding{Unit: money.New(100), Mode: money.RoundDown, Residual: money.ResidualFirst}
+	res = Calculate(money.New(800000), 3, rule)
+	assert.Equal(t, money.New(274700), res.InstallmentAmount)
+	assert.Equal(t, money.New(274760), Schedule(res, 3, rule.Rounding)[0].Amount)
+}
+
+// TestSchedule_Property checks for every tenor and rounding policy that the installments
+// always add back up to the total, principal, interest and admin fee exactly
+func TestSchedule_Property(t *testing.T) {
+	rule := Rule{Method: MethodEffective, InterestRate: 23.5, FeeType: FeePercent, FeeValue: 1.75, FeeBase: FeeBasePrincipalInterest}
+	units := []money.Money{money.New(1), money.New(100), money.New(1000)}
+	modes := []money.RoundingMode{money.RoundDown, money.RoundUp, money.RoundHalfEven}
+	residuals := []string{money.ResidualFirst, money.ResidualLast}
+
+	for tenor := 1; tenor <= 60; tenor++ {
+		for _, unit := range units {
+			for _, mode := range modes {
+				for _, residual := range residuals {
+					rule.Rounding = money.Rounding{Unit: unit, Mode: mode, Residual: residual}
+
+					property := func(rupiah uint32) bool {
+						// Minimum principal of 100.000 rupiah
+						principal := money.New(int64(rupiah%1_000_000_000) + 100_000)
+						res := Calculate(principal, tenor, rule)
+						installments := Schedule(res, tenor, rule.Rounding)
+						if len(installments) != tenor {
+							return false
+						}
+
+						var total, principals, interests, fees money.Money
+						for _, inst := range installments {
+							if inst.Principal.IsNegative() || money.Sum(inst.Principal, inst.Interest, inst.Fee) != inst.Amount {
+								return false
+							}
+							total = total.Add(inst.Amount)
+							principals = principals.Add(inst.Principal)
+							interests = interests.Add(inst.Interest)
+							fees = fees.Add(inst.Fee)
+						}
+						return total == res.TotalAmount && principals == res.Principal &&
+							interests == res.InterestAmount && fees == res.AdminFee
+					}
+
+					if err := quick.Check(property, &quick.Config{MaxCount: 100}); err != nil {
+						t.Errorf("tenor %d, rounding %+v: %v", tenor, rule.Rounding, err)
+					}
+				}
+			}
+		}
+	}
+}
@@ -9,10 +9,12 @@ package response
 
 const (
 	ErrUnauthorized = "UNAUTHORIZED"
+	ErrForbidden    = "FORBIDDEN"
 
 	MsgMissingAuthorization = "Missing authorization token"
 	MsgInvalidToken         = "The token provided is invalid"
 	MsgLoginRequired        = "Authentication required. Please provide a valid token"
+	MsgForbidden            = "You do not have permission to perform this action"
 )
 
 func Authorization(httpCode int, code string, msg string, err ...error) ErrorResponse {
@@ -54,6 +54,12 @@ func parseDuplicateValue(mysqlErr *mysql.MySQLError) (string, string, bool) {
 	return "", "", false
 }
 
+// IsDuplicateError check if err is unique constraint error
+func IsDuplicateError(err error) bool {
+	var e *mysql.MySQLError
+	return errors.As(err, &e) && e.Number == 1062
+}
+
 // DatabaseHelper is helper to unique constraint error
 func DatabaseHelper(err error, mapIndexKey map[string]string, span ...SpanInterface) ErrorResponse {
 	var e *mysql.MySQLError
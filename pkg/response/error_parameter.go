@@ -21,6 +21,28 @@ const (
 	MsgInvalidRequest    = "Invalid request parameter"
 	ErrInsufficientLimit = "INSUFFICIENT_LIMIT"
 	MsgInsufficientLimit = "Insufficient credit limit for this transaction."
+	ErrInvalidStatus     = "INVALID_STATUS"
+	MsgInvalidStatus     = "Transaction status does not allow this action."
+	ErrNoOutstanding     = "NO_OUTSTANDING"
+	MsgNoOutstanding     = "Transaction has no outstanding installment."
+	ErrQuoteExpired      = "QUOTE_EXPIRED"
+	MsgQuoteExpired      = "Settlement quote is expired or no longer valid. Please request a new quote."
+	ErrInsufficientPay   = "INSUFFICIENT_PAYMENT"
+	MsgInsufficientPay   = "Payment amount is less than the settlement amount."
+	ErrCancelExpired     = "CANCEL_WINDOW_EXPIRED"
+	MsgCancelExpired     = "Transaction can no longer be cancelled."
+	ErrPricingNotFound   = "PRICING_NOT_FOUND"
+	MsgPricingNotFound   = "No pricing is available for this product and tenor."
+	ErrIdempotencyReused = "IDEMPOTENCY_KEY_REUSED"
+	MsgIdempotencyReused = "Idempotency key is already used with a different request."
+	ErrIdempotencyLocked = "IDEMPOTENCY_IN_PROGRESS"
+	MsgIdempotencyLocked = "Request with the same idempotency key is still in progress."
+	MsgIdempotencyKey    = "Invalid X-Idempotency-Key header"
+	ErrHoldExpired       = "HOLD_EXPIRED"
+	MsgHoldExpired       = "Limit hold is expired. Please reserve the limit again."
+	MsgHoldStatus        = "Limit hold is no longer active."
+	ErrLimitConflict     = "LIMIT_CONFLICT"
+	MsgLimitConflict     = "Credit limit is being used by another transaction. Please try again."
 )
 
 type ErrorFields []FieldError
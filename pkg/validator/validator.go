@@ -11,6 +11,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"reflect"
 	"strings"
+	"xyz/pkg/money"
 )
 
 func New() *validator.Validate {
@@ -35,5 +36,13 @@ func New() *validator.Validate {
 		return name
 	})
 
+	// validate money as sen, so tags like `required` and `gt=0` work
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		if m, ok := field.Interface().(money.Money); ok {
+			return m.Sen()
+		}
+		return nil
+	}, money.Money{})
+
 	return validate
 }